@@ -0,0 +1,132 @@
+// C:\_Projects_Go\AcousticLog\internal\app\bandlimits.go
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	iofs "acousticlog/internal/io"
+	"acousticlog/internal/mathx"
+)
+
+// bandCheck — пороги по полосам (день/ночь) и детектор низкочастотного шума.
+// Пороги — по индексам полос банка -bands; NaN — для полосы порога нет.
+type bandCheck struct {
+	labels []string
+	day    []float64
+	night  []float64
+
+	lfMask  []bool  // полосы до -lf-cutoff включительно
+	lfLimit float64 // 0 — детектор выключен
+	lfDelta float64
+}
+
+// parseBandLimits — "63:75,125:66" → пороги по полосам банка (частоты — номинальные, как в sound_bands).
+func parseBandLimits(s string, labels []string) ([]float64, error) {
+	out := make([]float64, len(labels))
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		freq, lim, ok := strings.Cut(item, ":")
+		if !ok {
+			return nil, fmt.Errorf("%q: ожидается частота:порог", item)
+		}
+		idx := -1
+		for i, l := range labels {
+			if l == strings.TrimSpace(freq) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("%q: нет полосы %s Гц (доступны %s)", item, freq, strings.Join(labels, ", "))
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(lim), 64)
+		if err != nil || v <= 0 {
+			return nil, fmt.Errorf("%q: порог должен быть числом > 0", item)
+		}
+		out[idx] = v
+	}
+	return out, nil
+}
+
+// newBandCheck — nil, если ни пороги по полосам, ни НЧ-детектор не заданы.
+func newBandCheck(cfg *Config, mode mathx.Bands) (*bandCheck, error) {
+	if cfg.BandLimitsDay == "" && cfg.BandLimitsNight == "" && cfg.LFLimit == 0 {
+		return nil, nil
+	}
+	if mode == mathx.BandsOff {
+		return nil, errors.New("band-limits/lf-limit требуют полосового анализа (-bands octave|third)")
+	}
+	bc := &bandCheck{labels: mathx.BandLabels(mode), lfLimit: cfg.LFLimit, lfDelta: cfg.LFDelta}
+	var err error
+	if bc.day, err = parseBandLimits(cfg.BandLimitsDay, bc.labels); err != nil {
+		return nil, fmt.Errorf("band-limits-day %w", err)
+	}
+	if bc.night, err = parseBandLimits(cfg.BandLimitsNight, bc.labels); err != nil {
+		return nil, fmt.Errorf("band-limits-night %w", err)
+	}
+	bc.lfMask = make([]bool, len(bc.labels))
+	for i, l := range bc.labels {
+		f, _ := strconv.ParseFloat(l, 64)
+		bc.lfMask[i] = f <= cfg.LFCutoff
+	}
+	return bc, nil
+}
+
+// bandExceeded — уровень хотя бы в одной полосе (дБ) не ниже её порога для периода.
+func (bc *bandCheck) bandExceeded(bands []float64, night bool) bool {
+	limits := bc.day
+	if night {
+		limits = bc.night
+	}
+	for i, l := range bands {
+		if !math.IsNaN(l) && !math.IsNaN(limits[i]) && l >= limits[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// lowFrequency — НЧ-уровень (энергетическая сумма полос до -lf-cutoff, без коррекции)
+// не ниже -lf-limit и на -lf-delta выше широкополосного скорректированного уровня
+// (у A-коррекции бас почти не виден).
+func (bc *bandCheck) lowFrequency(bandsMS []float64, broadband, splOffset float64) bool {
+	if bc.lfLimit == 0 {
+		return false
+	}
+	var sum float64
+	for i, ms := range bandsMS {
+		if bc.lfMask[i] && !math.IsNaN(ms) {
+			sum += ms
+		}
+	}
+	if sum <= 0 {
+		return false
+	}
+	lf := math.Max(0, mathx.PowerToDB(sum)+splOffset)
+	return lf >= bc.lfLimit && lf-broadband >= bc.lfDelta
+}
+
+// bandStatus — статус буфера по полосам: LOWFREQ важнее BAND; "" — без события.
+func (a *App) bandStatus(lv levels, night bool) string {
+	if a.bandCheck == nil || lv.bands == nil {
+		return ""
+	}
+	if a.bandCheck.lowFrequency(lv.bands, lv.leq, a.splOffset) {
+		return iofs.EventKindLowFreq
+	}
+	if a.bandCheck.bandExceeded(a.bandSPL(lv.bands), night) {
+		return iofs.EventKindBand
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+// C:\_Projects_Go\AcousticLog\internal\app\bands.go
+
+package app
+
+import (
+	"math"
+	"time"
+
+	iofs "acousticlog/internal/io"
+	"acousticlog/internal/mathx"
+)
+
+// bandAccum — энергия по полосам за текущее окно -band-interval.
+type bandAccum struct {
+	start  time.Time
+	energy []float64 // Σ ms·dt по полосам
+	dur    float64
+}
+
+// bandSPL — средние квадраты по полосам → дБ с калибровкой (NaN сохраняется).
+func (a *App) bandSPL(ms []float64) []float64 {
+	out := make([]float64, len(ms))
+	for i, v := range ms {
+		if math.IsNaN(v) || v <= 0 {
+			out[i] = math.NaN()
+			continue
+		}
+		out[i] = math.Max(0, mathx.PowerToDB(v)+a.splOffset)
+	}
+	return out
+}
+
+// flushBands — строка INTERVAL за закончившееся окно (force — и за текущее, неполное).
+func (a *App) flushBands(now time.Time, force bool) {
+	acc := &a.bandAcc
+	if a.bandBank == nil || acc.dur <= 0 {
+		return
+	}
+	if !force && now.Before(acc.start.Add(a.bandInterval)) {
+		return
+	}
+	ms := make([]float64, len(acc.energy))
+	for i, e := range acc.energy {
+		ms[i] = e / acc.dur
+	}
+	a.enqueueCSV(a.chBandCSV, iofs.BandRow{
+		Time: acc.start, Kind: "INTERVAL", Duration: acc.dur, Levels: a.bandSPL(ms),
+	}.Record())
+	acc.dur = 0
+	clear(acc.energy)
+}
+
+// addBands — учёт буфера (средние квадраты по полосам, длительность dt с) в текущем окне.
+func (a *App) addBands(now time.Time, ms []float64, dt float64) {
+	if a.bandBank == nil || ms == nil {
+		return
+	}
+	acc := &a.bandAcc
+	if acc.energy == nil {
+		acc.energy = make([]float64, len(ms))
+	}
+	acc.start = alignStart(now, a.bandInterval)
+	for i, v := range ms {
+		acc.energy[i] += v * dt
+	}
+	acc.dur += dt
+}
+
+// bandEvent — спектр буфера события рядом с интервальными строками (со ссылкой на WAV).
+func (a *App) bandEvent(now time.Time, kind string, ms []float64, dt float64, wav string) {
+	if a.bandBank == nil || ms == nil {
+		return
+	}
+	a.enqueueCSV(a.chBandCSV, iofs.BandRow{
+		Time: now, Kind: kind, Duration: dt, Levels: a.bandSPL(ms), WAV: wav,
+	}.Record())
+}
@@ -0,0 +1,116 @@
+// C:\_Projects_Go\AcousticLog\internal\app\clock.go
+
+package app
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	iofs "acousticlog/internal/io"
+)
+
+// gapWindow — окно проверки разрыва: отставание часов берётся наименьшее за окно, чтобы
+// один поздно замеченный буфер (загрузка системы) не считался потерей звука.
+const gapWindow = time.Second
+
+// clockShift — что произошло со шкалой на буфере.
+type clockShift int
+
+const (
+	shiftNone   clockShift = iota
+	shiftResync            // плановая перепривязка: сдвиг — дрейф счётчика относительно часов
+	shiftGap               // разрыв захвата: звук шёл, а буферов не было, сдвиг — длина пропуска
+)
+
+// sampleClock — время буферов живого источника по счётчику сэмплов.
+//
+// Живые источники ставят на кадр момент, когда цикл опроса заметил готовый буфер, минус
+// его длительность: это начало буфера плюс задержка опроса и планировщика, и она прыгает
+// от кадра к кадру. Счётчик от привязки даёт ровную шкалу; раз в -clock-resync она сдвигается
+// на наименьшее за период отставание часов — буфер, замеченный быстрее всех, точнее всех.
+// Если же наименьшее отставание за gapWindow выросло против прошлого окна больше чем на
+// -gap-min, звук был потерян — шкала сразу сдвигается на прирост. Сравнение с прошлым
+// окном, а не с нулём, нужно, чтобы медленный дрейф (особенно при -clock-resync 0)
+// не копился в ложный разрыв.
+type sampleClock struct {
+	rate   int
+	resync time.Duration // 0 — без плановой перепривязки (только разрывы)
+	gapMin time.Duration
+
+	anchor  time.Time // время сэмпла 0 после последней привязки
+	samples int64     // сэмплов с привязки
+	synced  time.Time // когда была привязка (по часам)
+	minOff  time.Duration
+	haveOff bool
+
+	winStart time.Time // окно проверки разрыва (по часам)
+	winMin   time.Duration
+	winN     int           // буферов в окне
+	lastWin  time.Duration // наименьшее отставание прошлого окна — текущий дрейф
+
+	maxDrift time.Duration // наибольший по модулю дрейф за сессию (для сводки)
+}
+
+// stamp — время начала буфера из n сэмплов, которое источник поставил по часам (wall),
+// и сдвиг шкалы на нём (часы минус счётчик), если он был. При разрыве буферы внутри окна ещё стоят на старой
+// шкале — место пропуска известно с точностью до gapWindow.
+func (c *sampleClock) stamp(wall time.Time, n int) (t time.Time, shift time.Duration, kind clockShift) {
+	if c.anchor.IsZero() {
+		c.anchor, c.synced = wall, wall
+	}
+	t = c.anchor.Add(time.Duration(float64(c.samples) / float64(c.rate) * float64(time.Second)))
+	off := wall.Sub(t)
+	if !c.haveOff || off < c.minOff {
+		c.minOff, c.haveOff = off, true
+	}
+	if c.winN == 0 {
+		c.winStart, c.winMin = wall, off
+	}
+	c.winMin = min(c.winMin, off)
+	c.winN++
+	winDone := wall.Sub(c.winStart) >= gapWindow
+	if winDone {
+		c.winN = 0
+	}
+
+	switch {
+	case winDone && c.winMin-c.lastWin > c.gapMin:
+		// дрейф остаётся в шкале до плановой перепривязки, сдвиг — только пропуск
+		shift, kind = c.winMin-c.lastWin, shiftGap
+	case c.resync > 0 && wall.Sub(c.synced) >= c.resync:
+		shift, kind = c.minOff, shiftResync
+		if shift.Abs() > c.maxDrift.Abs() {
+			c.maxDrift = shift
+		}
+		c.synced, c.lastWin = wall, c.lastWin-shift
+	default:
+		if winDone {
+			c.lastWin = c.winMin
+		}
+		c.samples += int64(n)
+		return t, 0, shiftNone
+	}
+	// перепривязка: текущий буфер — уже на новой шкале
+	t = t.Add(shift)
+	c.anchor, c.samples, c.haveOff, c.winN = t, int64(n), false, 0
+	return t, shift, kind
+}
+
+// restart — новая привязка с первого буфера: после переоткрытия источника счётчик
+// начинается заново, и пропуск уже записан как потеря устройства.
+func (c *sampleClock) restart() {
+	c.anchor, c.samples, c.haveOff, c.winN, c.lastWin = time.Time{}, 0, false, 0, 0
+}
+
+// logClockDrift — перепривязка шкалы: строка SYSTEM в sound_all, чтобы по журналу было видно,
+// насколько расходились счётчик и часы.
+func (a *App) logClockDrift(when time.Time, drift time.Duration) {
+	atomic.AddUint64(&a.stats.ClockResyncs, 1)
+	a.enqueueCSV(a.chAllCSV, iofs.Row{
+		Time:   when.In(a.loc),
+		Mode:   "SYSTEM",
+		Status: fmt.Sprintf("CLOCK_DRIFT_%+.1fms", float64(drift)/float64(time.Millisecond)),
+		WAV:    "NO_WAV",
+	}.Record())
+}
@@ -0,0 +1,59 @@
+// C:\_Projects_Go\AcousticLog\internal\app\clock_test.go
+
+package app
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// Дрейф счётчика (100 ppm) и дрожание опроса без плановой перепривязки не должны
+// давать ложных разрывов, а настоящий пропуск должен найтись один раз и своей длины.
+func TestSampleClockGapIgnoresDrift(t *testing.T) {
+	const rate, n = 16000, 3200 // буфер 200 мс
+	bufDur := 200 * time.Millisecond
+	base := time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		name   string
+		resync time.Duration
+		lossAt int           // номер буфера, перед которым пропал звук; -1 — без пропуска
+		loss   time.Duration // длина пропуска
+	}{
+		{"drift, resync off", 0, -1, 0},
+		{"drift, resync 10m", 10 * time.Minute, -1, 0},
+		{"loss, resync off", 0, 20000, 500 * time.Millisecond},
+		{"loss, resync 10m", 10 * time.Minute, 20000, 500 * time.Millisecond},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &sampleClock{rate: rate, resync: tc.resync, gapMin: 100 * time.Millisecond}
+			rnd := rand.New(rand.NewSource(1))
+			var gaps []time.Duration
+			lost := time.Duration(0)
+			for i := 0; i < 36000; i++ { // два часа
+				if i == tc.lossAt {
+					lost = tc.loss
+				}
+				real := time.Duration(float64(i) * float64(bufDur) * (1 + 100e-6))
+				jitter := time.Duration(rnd.Intn(30)) * time.Millisecond
+				_, shift, kind := c.stamp(base.Add(real+lost+jitter), n)
+				if kind == shiftGap {
+					gaps = append(gaps, shift)
+				}
+			}
+			if tc.lossAt < 0 {
+				if len(gaps) != 0 {
+					t.Fatalf("ложные разрывы: %v", gaps)
+				}
+				return
+			}
+			if len(gaps) != 1 {
+				t.Fatalf("разрывов %d (%v), ожидался один", len(gaps), gaps)
+			}
+			if d := gaps[0] - tc.loss; d < -40*time.Millisecond || d > 40*time.Millisecond {
+				t.Fatalf("длина разрыва %v, ожидалось ≈ %v", gaps[0], tc.loss)
+			}
+		})
+	}
+}
@@ -5,24 +5,98 @@ package app
 import (
 	"errors"
 	"flag"
+	"strings"
+	"time"
+
+	iofs "acousticlog/internal/io"
 )
 
 type Config struct {
 	NoHourlyMerge  bool
 	HourlyMergeOut string
+	MergeKinds     []string // виды событий в часовой склейке (EXCEEDED, IMPULSE, BAND, LOWFREQ)
+	NoSpectrogram  bool     // не рисовать PNG-спектрограммы клипов и склеек
+
+	// output layout (шаблоны путей, см. io.Layout)
+	OutRoot            string
+	WAVPathTemplate    string
+	CSVPathTemplate    string
+	MergedPathTemplate string
 
 	// thresholds & logic
-	SPLOffset    float64
-	DayLimit     float64
-	NightLimit   float64
-	DayStartHHMM string
-	DayEndHHMM   string
-	ImpulseDelta float64
-	LogAll       bool
+	SPLOffset     float64
+	ClipLevel     float64 // дБFS: сэмпл не ниже — вход перегружен (CLIPPED)
+	Weighting     string  // A | C | Z
+	TimeWeighting string  // F | S | I | EQ
+	DayLimit      float64
+	NightLimit    float64
+	DayStartHHMM  string
+	DayEndHHMM    string
+	Periods       string  // "DAY=07:00/55,EVENING=19:00/50,NIGHT=23:00/45"; пусто — день/ночь
+	Schedule      string  // файл недельного расписания периодов; пусто — каждый день по Periods
+	Holidays      string  // файл с датами праздников (профиль holiday или воскресенья)
+	Exclude       string  // окна исключения через ";" (exclude.go)
+	ExcludeFile   string  // то же построчно из файла
+	Rules         string  // файл правил тревог (rules.go); пусто — без правил
+	RelativeDelta float64 // >0 — порог = фон часа + столько дБ вместо абсолютного
+	ImpulseDelta  float64
+	LogAll        bool // устарел: полный лог — всегда в sound_all
+
+	// impulse detector (фон — L90 за ImpulseWindow)
+	ImpulseWindow    time.Duration
+	ImpulsePeakDelta float64 // пик сэмпла над фоном, дБ; 0 — критерий выключен
+	ImpulseCrest     float64 // минимальный пик-фактор буфера для критерия по пику, дБ
+
+	// exceedance gating
+	ExceedHyst float64       // превышение выключается ниже порога на столько дБ
+	ExceedMin  time.Duration // столько непрерывно выше порога, чтобы превышение засчиталось
+	ExceedGap  time.Duration // новое превышение — не раньше этого после конца прошлого
+
+	// event aggregation
+	EventHang time.Duration // событие закрывается, если срабатываний нет дольше
+	EventMax  time.Duration // длинное событие режется на части; 0 — без ограничения
+	PreRoll   time.Duration // звук до срабатывания в клипе события
+	PostRoll  time.Duration // звук после последнего срабатывания
+
+	// interval statistics
+	StatsIntervals string // "1m,15m,1h"; пусто — выключено
+
+	// band analysis
+	Bands        string // octave | third | off
+	BandInterval string // окно строк sound_bands, "1s"
+
+	// band thresholds & low-frequency detector
+	BandLimitsDay   string  // "63:75,125:66,…" — по номинальным частотам полос
+	BandLimitsNight string  // то же для ночных периодов
+	LFLimit         float64 // порог НЧ-уровня (Z, полосы до LFCutoff); 0 — детектор выключен
+	LFDelta         float64 // на сколько НЧ-уровень должен превышать широкополосный
+	LFCutoff        float64 // верхняя номинальная частота НЧ-полос, Гц
 
 	// audio
+	Source     string // winmm | wav | pipe | synth
 	SampleRate int
 	BufferMs   int
+	// живой источник: время буферов — по счётчику сэмплов, перепривязка к часам с таким шагом
+	ClockResync time.Duration
+	GapMin      time.Duration // отставание счётчика от часов, с которого это разрыв захвата
+	Watchdog    time.Duration // сторож живого захвата: нет буферов столько — переоткрыть; 0 — выкл.
+	// постоянный сигнал (все сэмплы равны) столько — тоже потеря; 0 — не проверять
+	// (цифровая тишина нулями бывает у исправных источников)
+	WatchdogFlat time.Duration
+
+	// replay (-source wav / synth)
+	ReplayIn       string
+	ReplayStart    string // "YYYY-MM-DD HH:MM:SS" в -tz; пусто — текущее время
+	ReplayRealtime bool
+
+	// raw PCM (-source pipe)
+	PipeCmd      string // пусто или "-" — stdin
+	PipeFormat   string
+	PipeChannels int
+
+	// generator (-source synth)
+	SynthScenario string
+	SynthSeed     int64
 
 	// runtime
 	Timezone   string
@@ -41,6 +115,9 @@ type Config struct {
 
 	// csv
 	CSVDelim rune
+
+	// время программы; nil — системное (не флаг: подменяется в тестах)
+	Clock Clock
 }
 
 func ParseFlags() (*Config, error) {
@@ -56,38 +133,98 @@ func ParseFlags() (*Config, error) {
 	if quiet {
 		stripToken("/quiet")
 	}
+	return parseArgs(_osArgs()[1:], autoMode, quiet)
+}
 
-	// --- флаги
-	spl := flag.Float64("spl-offset", 114, "")
-	day := flag.Float64("day-limit", 55, "")
-	night := flag.Float64("night-limit", 45, "")
-	dayStart := flag.String("day-start", "07:00", "")
-	dayEnd := flag.String("day-end", "23:00", "")
-	stopAt := flag.String("stop-at", "02:00", "")
-
-	sr := flag.Int("samplerate", 16000, "")
-	bufms := flag.Int("duration", 200, "")
-	tz := flag.String("tz", "Asia/Dushanbe", "")
-
-	logAll := flag.Bool("log-all", false, "")
-	impulse := flag.Float64("impulse-delta", 15, "")
-
-	warnMB := flag.Uint64("disk-warn-mb", 100, "")
-	stopMB := flag.Uint64("disk-stop-mb", 50, "")
-
-	lines := flag.Int("live-lines", 70, "")
-	noClear := flag.Bool("live-no-clear", false, "")
-	appendL := flag.Bool("append-live", false, "")
-	csvDelimStr := flag.String("csv-delim", ";", "")
-	wavDepth := flag.Int("live-wav-depth", 3, "")
-
-	consolePage := flag.Bool("console-page", false, "")
-	consolePageSize := flag.Int("console-page-size", 70, "")
-
-	noHourly := flag.Bool("no-hourly-merge", false, "")
-	hourlyOut := flag.String("hourly-merge-out", "_Merged_Exceeded", "")
+// parseArgs — флаги командной строки (без имени программы и токенов /auto, /quiet).
+// Свой FlagSet, а не глобальный: тесты разбирают аргументы столько раз, сколько нужно.
+func parseArgs(args []string, autoMode, quiet bool) (*Config, error) {
+	fs := flag.NewFlagSet("acousticlog", flag.ExitOnError)
 
-	flag.Parse()
+	// --- флаги
+	spl := fs.Float64("spl-offset", 114, "")
+	clipLevel := fs.Float64("clip-level", -0.1, "")
+	weighting := fs.String("weighting", "A", "")
+	timeWeighting := fs.String("time-weighting", "F", "")
+	day := fs.Float64("day-limit", 55, "")
+	night := fs.Float64("night-limit", 45, "")
+	dayStart := fs.String("day-start", "07:00", "")
+	dayEnd := fs.String("day-end", "23:00", "")
+	periods := fs.String("periods", "", "")
+	stopAt := fs.String("stop-at", "02:00", "")
+
+	source := fs.String("source", "winmm", "")
+	sr := fs.Int("samplerate", 16000, "")
+	bufms := fs.Int("duration", 200, "")
+	clockResync := fs.Duration("clock-resync", 10*time.Minute, "")
+	gapMin := fs.Duration("gap-min", 100*time.Millisecond, "")
+	watchdogAfter := fs.Duration("watchdog", 10*time.Second, "")
+	watchdogFlat := fs.Duration("watchdog-flat", 0, "")
+	tz := fs.String("tz", "Asia/Dushanbe", "")
+
+	replayIn := fs.String("replay-in", "", "")
+	replayStart := fs.String("replay-start", "", "")
+	replayRealtime := fs.Bool("replay-realtime", false, "")
+
+	pipeCmd := fs.String("pipe-cmd", "", "")
+	pipeFormat := fs.String("pipe-format", "s16le", "")
+	pipeChannels := fs.Int("pipe-channels", 1, "")
+
+	synthScenario := fs.String("synth", "", "")
+	synthSeed := fs.Int64("synth-seed", 1, "")
+
+	logAll := fs.Bool("log-all", false, "")
+	statsIntervals := fs.String("stats-intervals", "1m,15m,1h", "")
+	impulse := fs.Float64("impulse-delta", 15, "")
+	relative := fs.Float64("relative-delta", 0, "")
+	schedule := fs.String("schedule", "", "")
+	rules := fs.String("rules", "", "")
+	exclude := fs.String("exclude", "", "")
+	excludeFile := fs.String("exclude-file", "", "")
+	holidays := fs.String("holidays", "", "")
+	impulseWindow := fs.Duration("impulse-window", 30*time.Second, "")
+	impulsePeak := fs.Float64("impulse-peak-delta", 30, "")
+	impulseCrest := fs.Float64("impulse-crest", 15, "")
+	exceedHyst := fs.Float64("exceed-hyst", 2, "")
+	exceedMin := fs.Duration("exceed-min", 0, "")
+	exceedGap := fs.Duration("exceed-gap", 0, "")
+	eventHang := fs.Duration("event-hang", 2*time.Second, "")
+	eventMax := fs.Duration("event-max", 10*time.Minute, "")
+	preRoll := fs.Duration("pre-roll", 2*time.Second, "")
+	postRoll := fs.Duration("post-roll", time.Second, "")
+	bands := fs.String("bands", "octave", "")
+	bandInterval := fs.String("band-interval", "1s", "")
+	bandLimitsDay := fs.String("band-limits-day", "", "")
+	bandLimitsNight := fs.String("band-limits-night", "", "")
+	lfLimit := fs.Float64("lf-limit", 0, "")
+	lfDelta := fs.Float64("lf-delta", 15, "")
+	lfCutoff := fs.Float64("lf-cutoff", 160, "")
+
+	warnMB := fs.Uint64("disk-warn-mb", 100, "")
+	stopMB := fs.Uint64("disk-stop-mb", 50, "")
+
+	lines := fs.Int("live-lines", 70, "")
+	noClear := fs.Bool("live-no-clear", false, "")
+	appendL := fs.Bool("append-live", false, "")
+	csvDelimStr := fs.String("csv-delim", ";", "")
+	wavDepth := fs.Int("live-wav-depth", 3, "")
+
+	consolePage := fs.Bool("console-page", false, "")
+	consolePageSize := fs.Int("console-page-size", 70, "")
+
+	noHourly := fs.Bool("no-hourly-merge", false, "")
+	hourlyOut := fs.String("hourly-merge-out", "_Merged_Exceeded", "")
+	mergeKinds := fs.String("merge-kinds", iofs.EventKindExceeded, "")
+	noSpectrogram := fs.Bool("no-spectrogram", false, "")
+
+	outRoot := fs.String("out-root", "", "")
+	wavPath := fs.String("wav-path", iofs.DefaultWAVTemplate, "")
+	csvPath := fs.String("csv-path", iofs.DefaultCSVTemplate, "")
+	mergedPath := fs.String("merged-path", "", "")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
 
 	// --- приведение поведения консоли
 	// 1) Если включена постраничность — принудительно noClear=false и назначаем размер страницы.
@@ -108,15 +245,59 @@ func ParseFlags() (*Config, error) {
 	if *day <= 0 || *night <= 0 || *day < *night {
 		return nil, errors.New("некорректные пороги: day>0, night>0, day>=night")
 	}
+	if *relative < 0 {
+		return nil, errors.New("relative-delta должен быть ≥ 0")
+	}
 	if *impulse <= 0 {
 		return nil, errors.New("impulse-delta должен быть > 0")
 	}
+	if *impulseWindow < time.Second || *impulsePeak < 0 || *impulseCrest < 0 {
+		return nil, errors.New("impulse-window должен быть ≥ 1s, impulse-peak-delta/impulse-crest ≥ 0")
+	}
+	if *exceedHyst < 0 || *exceedMin < 0 || *exceedGap < 0 {
+		return nil, errors.New("exceed-hyst/exceed-min/exceed-gap должны быть ≥ 0")
+	}
+	if *eventHang <= 0 || *eventMax < 0 {
+		return nil, errors.New("event-hang должен быть > 0, event-max ≥ 0")
+	}
+	if *preRoll < 0 || *postRoll < 0 || *preRoll > time.Minute {
+		return nil, errors.New("pre-roll должен быть от 0 до 1m, post-roll ≥ 0")
+	}
+	if *clipLevel > 0 || *clipLevel < -20 {
+		return nil, errors.New("clip-level должен быть от -20 до 0 дБFS")
+	}
+	if *clockResync < 0 || *gapMin <= 0 || *watchdogAfter < 0 || *watchdogFlat < 0 {
+		return nil, errors.New("clock-resync, watchdog и watchdog-flat должны быть ≥ 0, gap-min > 0")
+	}
+	// как в newSource: -source WAV — тот же источник, и проверки ниже должны его узнать
+	*source = strings.ToLower(strings.TrimSpace(*source))
+	if *source == "wav" && *replayIn == "" {
+		return nil, errors.New("для -source wav нужен -replay-in <файл.wav>")
+	}
+	if *source == "synth" && *synthScenario == "" {
+		return nil, errors.New("для -source synth нужен сценарий -synth \"pink 10s level=60; ...\"")
+	}
+	if *lfLimit < 0 || *lfDelta < 0 || *lfCutoff <= 0 {
+		return nil, errors.New("lf-limit/lf-delta должны быть ≥ 0, lf-cutoff > 0")
+	}
+	// виды проверяются в Run: кроме встроенных допустимы имена правил -rules
+	var kinds []string
+	for _, k := range strings.Split(*mergeKinds, ",") {
+		if k = strings.ToUpper(strings.TrimSpace(k)); k != "" {
+			kinds = append(kinds, k)
+		}
+	}
 	if *wavDepth < 2 {
 		*wavDepth = 2
 	} else if *wavDepth > 4 {
 		*wavDepth = 4
 	}
 
+	// --- шаблон склейки по умолчанию учитывает -hourly-merge-out
+	if *mergedPath == "" {
+		*mergedPath = "{root}/{date}/WAV/" + *hourlyOut + "/merged_exceeded_{date}_{hour}.wav"
+	}
+
 	// --- csv delimiter: корректно берём первую руну (а не первый байт)
 	delim := ';'
 	if *csvDelimStr != "" {
@@ -128,18 +309,77 @@ func ParseFlags() (*Config, error) {
 
 	return &Config{
 		// thresholds & logic
-		SPLOffset:    *spl,
-		DayLimit:     *day,
-		NightLimit:   *night,
-		DayStartHHMM: *dayStart,
-		DayEndHHMM:   *dayEnd,
-		ImpulseDelta: *impulse,
-		LogAll:       *logAll,
+		SPLOffset:     *spl,
+		ClipLevel:     *clipLevel,
+		Weighting:     *weighting,
+		TimeWeighting: *timeWeighting,
+		DayLimit:      *day,
+		NightLimit:    *night,
+		DayStartHHMM:  *dayStart,
+		DayEndHHMM:    *dayEnd,
+		Periods:       *periods,
+		Schedule:      *schedule,
+		Holidays:      *holidays,
+		Rules:         *rules,
+		Exclude:       *exclude,
+		ExcludeFile:   *excludeFile,
+		RelativeDelta: *relative,
+		ImpulseDelta:  *impulse,
+		LogAll:        *logAll,
+
+		// impulse detector
+		ImpulseWindow:    *impulseWindow,
+		ImpulsePeakDelta: *impulsePeak,
+		ImpulseCrest:     *impulseCrest,
+
+		// exceedance gating
+		ExceedHyst: *exceedHyst,
+		ExceedMin:  *exceedMin,
+		ExceedGap:  *exceedGap,
+
+		// event aggregation
+		EventHang: *eventHang,
+		EventMax:  *eventMax,
+		PreRoll:   *preRoll,
+		PostRoll:  *postRoll,
+
+		// interval statistics
+		StatsIntervals: *statsIntervals,
+
+		// band analysis
+		Bands:        *bands,
+		BandInterval: *bandInterval,
+
+		BandLimitsDay:   *bandLimitsDay,
+		BandLimitsNight: *bandLimitsNight,
+		LFLimit:         *lfLimit,
+		LFDelta:         *lfDelta,
+		LFCutoff:        *lfCutoff,
 
 		// audio
+		Source:     *source,
 		SampleRate: *sr,
 		BufferMs:   *bufms,
 
+		ClockResync:  *clockResync,
+		GapMin:       *gapMin,
+		Watchdog:     *watchdogAfter,
+		WatchdogFlat: *watchdogFlat,
+
+		// replay
+		ReplayIn:       *replayIn,
+		ReplayStart:    *replayStart,
+		ReplayRealtime: *replayRealtime,
+
+		// pipe
+		PipeCmd:      *pipeCmd,
+		PipeFormat:   *pipeFormat,
+		PipeChannels: *pipeChannels,
+
+		// generator
+		SynthScenario: *synthScenario,
+		SynthSeed:     *synthSeed,
+
 		// runtime
 		Timezone:   *tz,
 		StopAtHHMM: *stopAt,
@@ -161,6 +401,14 @@ func ParseFlags() (*Config, error) {
 		// hourly merge
 		NoHourlyMerge:  *noHourly,
 		HourlyMergeOut: *hourlyOut,
+		MergeKinds:     kinds,
+		NoSpectrogram:  *noSpectrogram,
+
+		// output layout
+		OutRoot:            *outRoot,
+		WAVPathTemplate:    *wavPath,
+		CSVPathTemplate:    *csvPath,
+		MergedPathTemplate: *mergedPath,
 	}, nil
 }
 
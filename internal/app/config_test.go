@@ -0,0 +1,22 @@
+// C:\_Projects_Go\AcousticLog\internal\app\config_test.go
+
+package app
+
+import "testing"
+
+// -source без учёта регистра: проверка обязательных флагов узнаёт источник так же,
+// как newSource.
+func TestParseArgsSourceCase(t *testing.T) {
+	for _, src := range []string{"WAV", "Synth"} {
+		if _, err := parseArgs([]string{"-source", src}, true, true); err == nil {
+			t.Errorf("-source %s без файла/сценария: ожидалась ошибка", src)
+		}
+	}
+	cfg, err := parseArgs([]string{"-source", "SYNTH", "-synth", "pink 1s"}, true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Source != "synth" {
+		t.Errorf("Source %q, want synth", cfg.Source)
+	}
+}
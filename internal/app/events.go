@@ -0,0 +1,299 @@
+// C:\_Projects_Go\AcousticLog\internal\app\events.go
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	iofs "acousticlog/internal/io"
+)
+
+// eventAcc — накопленные уровни события (энергия — Σ 10^(Leq/10)·dt, полосы — Σ ms·dt).
+type eventAcc struct {
+	energy float64
+	dur    float64
+	max    float64
+	peak   float64
+	crest  float64
+	bands  []float64
+}
+
+// openEvent — текущее событие: открывается первым сработавшим буфером и закрывается,
+// когда срабатываний нет дольше -event-hang (и набран -post-roll). Уровни «хвоста»
+// после последнего срабатывания в строку не идут (hit — снимок на последнем срабатывании),
+// а из звука остаётся -post-roll. Превышение, набравшее -exceed-min внутри события другого
+// вида, закрывает его и открывает своё: вид события не меняется, а пути клипов в уже
+// записанных строках sound_all остаются верными.
+type openEvent struct {
+	kind  string // по первому буферу (у условного — по подтвердившему), дальше не меняется
+	mode  string
+	limit float64
+	wav   string // путь клипа или DISK_LOW_SPACE_…; "" — не пишется
+	save  bool
+
+	tentative bool    // только буферы до -exceed-min; не подтвердится — событие отбрасывается
+	confirm   string  // условное событие: вид первого безусловного срабатывания (импульс и т.п.)
+	quality   string  // худшее качество буферов и разрывов за событие (колонка Quality)
+	baseline  float64 // фон L90 на момент открытия
+	detector  string  // правило срабатывания для колонки Detector
+
+	start     time.Time
+	end       time.Time // конец последнего сработавшего буфера
+	clipStart time.Time // начало клипа: start минус pre-roll
+
+	run, hit eventAcc
+	pcm      []byte
+	hitPCM   int
+}
+
+// eventTrack — поток событий: встроенные детекторы (EXCEEDED/IMPULSE/BAND/LOWFREQ)
+// или одно правило -rules. Потоки независимы: тревога правила не поглощается
+// превышением и наоборот.
+type eventTrack struct {
+	ev   *openEvent // nil — события нет
+	rule *rule      // nil — встроенные детекторы
+}
+
+// tracks — все потоки событий: основной и по правилу на каждое.
+func (a *App) tracks() []*eventTrack {
+	out := []*eventTrack{&a.events}
+	for _, r := range a.rules.list {
+		out = append(out, &r.track)
+	}
+	return out
+}
+
+// expireEvent — закрыть событие до обработки буфера now: истёк -event-hang, событие
+// достигло -event-max или начался новый час (клип должен попасть в склейку своего часа).
+func (a *App) expireEvent(t *eventTrack, now time.Time) {
+	ev := t.ev
+	if ev == nil {
+		return
+	}
+	if now.Sub(ev.end) >= max(a.cfg.EventHang, a.cfg.PostRoll) ||
+		(a.cfg.EventMax > 0 && now.Sub(ev.start) >= a.cfg.EventMax) ||
+		now.Format("2006-01-02 15") != ev.start.Format("2006-01-02 15") {
+		a.closeEvent(t)
+	}
+}
+
+// trackEvent — учёт буфера в событии; kind != "" — буфер сработал (tentative — условно,
+// превышение ещё не набрало -exceed-min). Возвращает WAV текущего события (для строки sound_all)
+// или "", пока события нет или оно условное.
+func (a *App) trackEvent(t *eventTrack, now time.Time, kind, mode string, lim float64, lv levels, dt float64, raw []byte, tentative bool) string {
+	ev := t.ev
+	gateOn := kind == iofs.EventKindExceeded && !tentative
+	if ev != nil && kind != "" && now.Sub(ev.end) >= a.cfg.EventHang {
+		// прошлое событие уже кончилось, дописывался только post-roll
+		a.closeEvent(t)
+		ev = nil
+	}
+	if ev != nil && gateOn && !ev.tentative && ev.kind != kind {
+		// превышение внутри импульса или полосы — своё событие
+		a.closeEvent(t)
+		ev = nil
+	}
+	if ev == nil {
+		if kind == "" {
+			return ""
+		}
+		// pre-roll — не раньше начала часа: клип должен попасть в склейку своего часа
+		pre := a.preRoll.tail(min(a.bytesFor(a.cfg.PreRoll), a.bytesFor(now.Sub(hourStart(now)))))
+		clipStart := now.Add(-time.Duration(float64(len(pre)) / float64(2*a.format.SampleRate) * float64(time.Second)))
+		ev = &openEvent{mode: mode, limit: lim, start: now, clipStart: clipStart, tentative: tentative,
+			baseline: a.baseline.level()}
+		if free := a.diskFreeMB; free > a.diskWarnMB {
+			ev.save = true
+			ev.pcm = append(ev.pcm, pre...)
+		} else {
+			ev.wav = fmt.Sprintf("DISK_LOW_SPACE_%.1fMB", float64(free))
+		}
+		a.setEventKind(t, ev, kind)
+		t.ev = ev
+	}
+
+	if lv.clipped > 0 {
+		ev.quality = iofs.WorseQuality(ev.quality, iofs.QualityClipped)
+	}
+	r := &ev.run
+	r.energy += math.Pow(10, lv.leq/10) * dt
+	r.dur += dt
+	r.max = math.Max(r.max, lv.level)
+	r.peak = math.Max(r.peak, lv.peak)
+	r.crest = math.Max(r.crest, crestFactor(lv, a.splOffset))
+	if lv.bands != nil {
+		if r.bands == nil {
+			r.bands = make([]float64, len(lv.bands))
+		}
+		for i, v := range lv.bands {
+			r.bands[i] += v * dt
+		}
+	}
+	if ev.save {
+		ev.pcm = append(ev.pcm, raw...)
+	}
+
+	if kind != "" {
+		ev.end = now.Add(time.Duration(dt * float64(time.Second)))
+		ev.hit = eventAcc{energy: r.energy, dur: r.dur, max: r.max, peak: r.peak, crest: r.crest,
+			bands: append([]float64(nil), r.bands...)}
+		ev.hitPCM = len(ev.pcm)
+		switch {
+		case gateOn:
+			ev.tentative = false
+		case ev.tentative && !tentative && ev.confirm == "":
+			// импульс подтверждает событие, только если превышение так и не наберётся
+			ev.confirm = kind
+		}
+	}
+	if ev.tentative {
+		return ""
+	}
+	return ev.wav
+}
+
+// markOpenEvents — понизить качество всех открытых событий (разрыв, потеря устройства, остановка).
+func (a *App) markOpenEvents(q string) {
+	for _, t := range a.tracks() {
+		if t.ev != nil {
+			t.ev.quality = iofs.WorseQuality(t.ev.quality, q)
+		}
+	}
+}
+
+// setEventKind — вид события, а с ним колонка Detector и путь клипа.
+func (a *App) setEventKind(t *eventTrack, ev *openEvent, kind string) {
+	ev.kind, ev.detector = kind, ""
+	switch {
+	case t.rule != nil:
+		ev.detector = t.rule.src
+	case kind == iofs.EventKindImpulse:
+		ev.detector = a.impulseRule()
+	}
+	if ev.save {
+		ev.wav = a.layout.WAVPath(ev.clipStart, kind)
+	}
+}
+
+// settleTentative — условное событие, превышение которого так и не набрало -exceed-min:
+// если внутри было другое срабатывание, событие остаётся с его видом, иначе отбрасывается.
+// Кольцо pre-roll не трогаем: этот звук ещё может попасть в следующее событие.
+func (a *App) settleTentative(t *eventTrack) {
+	ev := t.ev
+	if ev == nil || !ev.tentative {
+		return
+	}
+	if ev.confirm == "" {
+		t.ev = nil
+		return
+	}
+	ev.tentative = false
+	a.setEventKind(t, ev, ev.confirm)
+}
+
+// dropTentative — превышение сбросилось, не набрав -exceed-min.
+func (a *App) dropTentative() {
+	a.settleTentative(&a.events)
+}
+
+// closeEvent — строка sound_log, спектр события в sound_bands и клип одним WAV.
+func (a *App) closeEvent(t *eventTrack) {
+	a.settleTentative(t)
+	ev := t.ev
+	if ev == nil {
+		return
+	}
+	t.ev = nil
+	h := ev.hit
+
+	leq := 0.0
+	if h.dur > 0 {
+		leq = 10 * math.Log10(h.energy/h.dur)
+	}
+	a.enqueueCSV(a.chMainCSV, iofs.EventRow{
+		Start: ev.start, End: ev.end, Kind: ev.kind, Mode: ev.mode, Limit: ev.limit,
+		Leq: leq, Max: h.max, Peak: h.peak, Baseline: ev.baseline, Crest: h.crest,
+		Detector: ev.detector, WAV: ev.wav, Quality: ev.quality,
+	}.Record())
+	atomic.AddUint64(&a.stats.CSVEventsWritten, 1)
+
+	// спектр события — в sound_bands, чтобы было видно: бас это или речь
+	if h.bands != nil {
+		ms := make([]float64, len(h.bands))
+		for i, e := range h.bands {
+			ms[i] = e / h.dur
+		}
+		a.bandEvent(ev.start, ev.kind, ms, h.dur, ev.wav)
+	}
+
+	n := min(len(ev.pcm), ev.hitPCM+a.bytesFor(a.cfg.PostRoll))
+	if t.rule == nil {
+		// звук до конца клипа уже в нём — следующему событию в pre-roll остаётся только то, что после
+		a.preRoll.keep(len(ev.pcm) - n)
+	}
+	if ev.save {
+		a.queueWAV(wavTask{when: ev.clipStart, rate: a.format.SampleRate, pcm: ev.pcm[:n], kind: ev.kind})
+	}
+}
+
+// queueWAV — клип в очередь записи: офлайн-источник ждёт, живой — дропает при переполнении.
+func (a *App) queueWAV(task wavTask) {
+	a.wavPending.Add(1)
+	if a.waitWAV {
+		// офлайн-источник подождёт диск — клипы не теряем
+		a.chWAV <- task
+		return
+	}
+	select {
+	case a.chWAV <- task:
+	default:
+		// дроп без блокировки, но не молча: строка SYSTEM вместо клипа
+		a.wavPending.Done()
+		atomic.AddUint64(&a.stats.WAVDropped, 1)
+		end := task.when.Add(time.Duration(float64(len(task.pcm)/2) / float64(task.rate) * float64(time.Second)))
+		a.logSystem(task.when, end, "WAV_DROPPED", iofs.QualityOK, "", a.layout.WAVPath(task.when, task.kind))
+	}
+}
+
+// bytesFor — длительность d в байтах PCM конвейера (моно 16 бит), кратно сэмплу.
+func (a *App) bytesFor(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return 2 * int(d.Seconds()*float64(a.format.SampleRate))
+}
+
+func hourStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+// pcmRing — последние size байт PCM (pre-roll): держим не больше 2·size, сдвигаем редко.
+type pcmRing struct {
+	size int
+	buf  []byte
+}
+
+func (r *pcmRing) push(p []byte) {
+	if r.size <= 0 {
+		return
+	}
+	r.buf = append(r.buf, p...)
+	if len(r.buf) > 2*r.size {
+		r.buf = append(r.buf[:0], r.buf[len(r.buf)-r.size:]...)
+	}
+}
+
+// tail — копия последних n байт (не больше накопленного и size).
+func (r *pcmRing) tail(n int) []byte {
+	n = min(n, r.size, len(r.buf))
+	return append([]byte(nil), r.buf[len(r.buf)-n:]...)
+}
+
+// keep — оставить только последние n байт.
+func (r *pcmRing) keep(n int) {
+	n = min(n, len(r.buf))
+	r.buf = append(r.buf[:0], r.buf[len(r.buf)-n:]...)
+}
@@ -0,0 +1,112 @@
+// C:\_Projects_Go\AcousticLog\internal\app\events_test.go
+
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"acousticlog/internal/audio"
+	iofs "acousticlog/internal/io"
+)
+
+// newEventApp — App только с тем, что нужно учёту событий: строки sound_log и клипы
+// остаются в каналах, а не пишутся.
+func newEventApp(t *testing.T) *App {
+	t.Helper()
+	return &App{
+		cfg:        &Config{EventHang: time.Second},
+		format:     audio.Mono16(8000),
+		layout:     iofs.Layout{Root: t.TempDir(), WAV: iofs.DefaultWAVTemplate},
+		diskFreeMB: 1000,
+		diskWarnMB: 100,
+		loc:        time.UTC,
+		chMainCSV:  make(chan []string, 16),
+		chWAV:      make(chan wavTask, 16),
+		preRoll:    pcmRing{size: 8000},
+		baseline:   baseline{window: time.Minute},
+	}
+}
+
+// Условное превышение (gatePending) и импульс внутри него: событие получает вид
+// того срабатывания, которое его подтвердило, а превышение поверх другого события
+// открывает своё.
+func TestTentativeEventKind(t *testing.T) {
+	type step struct {
+		gate    gateState
+		impulse bool
+	}
+	type row struct {
+		kind, start string
+		detector    bool
+	}
+	for _, tc := range []struct {
+		name  string
+		steps []step
+		want  []row
+	}{
+		{"pending then off: dropped",
+			[]step{{gatePending, false}, {gatePending, false}, {gateOff, false}}, nil},
+		{"impulse while pending, gate off: IMPULSE",
+			[]step{{gatePending, false}, {gatePending, true}, {gateOff, false}},
+			[]row{{iofs.EventKindImpulse, "00:00:00.000", true}}},
+		{"impulse while pending, gate on: EXCEEDED",
+			[]step{{gatePending, false}, {gatePending, true}, {gateOn, false}, {gateOn, false}},
+			[]row{{iofs.EventKindExceeded, "00:00:00.000", false}}},
+		{"impulse, then exceedance: split",
+			[]step{{gateOff, true}, {gatePending, false}, {gateOn, false}, {gateOn, true}},
+			[]row{{iofs.EventKindImpulse, "00:00:00.000", true}, {iofs.EventKindExceeded, "00:00:00.400", false}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newEventApp(t)
+			base := time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC)
+			const dt = 0.2
+			raw := make([]byte, 2*1600)
+			for i, s := range tc.steps {
+				now := base.Add(time.Duration(i) * 200 * time.Millisecond)
+				// как в process: превышение важнее импульса, условное — только без срабатываний
+				kind := ""
+				switch {
+				case s.gate == gateOn:
+					kind = iofs.EventKindExceeded
+				case s.impulse:
+					kind = iofs.EventKindImpulse
+				}
+				tentative := kind == "" && s.gate == gatePending
+				if s.gate == gateOff {
+					a.dropTentative()
+				}
+				if tentative {
+					kind = iofs.EventKindExceeded
+				}
+				a.trackEvent(&a.events, now, kind, "DAY", 50, levels{leq: 60, level: 60}, dt, raw, tentative)
+				a.preRoll.push(raw)
+			}
+			a.closeEvent(&a.events)
+
+			var got []row
+			for len(a.chMainCSV) > 0 {
+				rec := <-a.chMainCSV
+				got = append(got, row{kind: rec[3], start: rec[0][11:], detector: rec[11] != ""})
+				if !strings.Contains(rec[12], rec[3]) {
+					t.Errorf("клип %s не в папке вида %s", rec[12], rec[3])
+				}
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("события %v, ожидалось %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("событие %d: %v, ожидалось %v", i, got[i], tc.want[i])
+				}
+			}
+			for len(a.chWAV) > 0 {
+				task := <-a.chWAV
+				if task.kind == "" {
+					t.Errorf("клип без вида события")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// C:\_Projects_Go\AcousticLog\internal\app\exceed.go
+
+package app
+
+import "time"
+
+// gateState — состояние детектора превышения для буфера.
+type gateState int
+
+const (
+	gateOff     gateState = iota
+	gatePending           // уровень выше порога, но -exceed-min ещё не набран
+	gateOn
+)
+
+// exceedGate — гистерезис превышения: включается на пороге периода после
+// -exceed-min непрерывного превышения, выключается ниже порога на -exceed-hyst дБ;
+// новое превышение — не раньше -exceed-gap после окончания прошлого.
+type exceedGate struct {
+	on    bool
+	since time.Time // начало непрерывного превышения (gatePending)
+	offAt time.Time // когда выключилось прошлое
+}
+
+func (a *App) exceedStep(now time.Time, level, lim, dt float64) gateState {
+	g := &a.exceed
+	if g.on {
+		if level < lim-a.cfg.ExceedHyst {
+			g.on, g.offAt = false, now
+			return gateOff
+		}
+		return gateOn
+	}
+	if level < lim || (!g.offAt.IsZero() && now.Sub(g.offAt) < a.cfg.ExceedGap) {
+		g.since = time.Time{}
+		return gateOff
+	}
+	if g.since.IsZero() {
+		g.since = now
+	}
+	if now.Add(time.Duration(dt*float64(time.Second))).Sub(g.since) >= a.cfg.ExceedMin {
+		g.on, g.since = true, time.Time{}
+		return gateOn
+	}
+	return gatePending
+}
@@ -0,0 +1,133 @@
+// C:\_Projects_Go\AcousticLog\internal\app\exclude.go
+
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// statusExcluded — статус буфера в окне исключения, где иначе было бы событие.
+const statusExcluded = "EXCLUDED"
+
+// exclusion — окно исключения (свой шум по расписанию): повторяющееся по дням недели
+// или разовое на дату. Конец раньше начала — окно через полночь.
+type exclusion struct {
+	label      string
+	days       [7]bool // повторяющееся
+	date       string  // разовое, "2006-01-02"
+	start, end int     // минуты от полуночи
+}
+
+// parseExclusions — записи через ";" (флаг) и построчно из файла:
+//
+//	[МЕТКА=]ДНИ ЧЧ:ММ-ЧЧ:ММ         WASHER=mon,thu 19:00-20:30
+//	[МЕТКА=]YYYY-MM-DD ЧЧ:ММ-ЧЧ:ММ  2025-10-25 10:00-16:00
+//
+// ДНИ — mon…sun, диапазоны mon-fri, * — каждый день.
+func parseExclusions(inline, path string) ([]exclusion, error) {
+	items := strings.Split(inline, ";")
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("exclude-file: %w", err)
+		}
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line, _, _ := strings.Cut(sc.Text(), "#")
+			items = append(items, line)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("exclude-file: %w", err)
+		}
+	}
+
+	var out []exclusion
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		ex, err := parseExclusion(item)
+		if err != nil {
+			return nil, fmt.Errorf("exclude %q: %w", item, err)
+		}
+		out = append(out, ex)
+	}
+	return out, nil
+}
+
+func parseExclusion(item string) (exclusion, error) {
+	var ex exclusion
+	if label, rest, ok := strings.Cut(item, "="); ok {
+		ex.label, item = strings.TrimSpace(label), rest
+	}
+	f := strings.Fields(item)
+	if len(f) != 2 {
+		return ex, fmt.Errorf("ожидается «дни|дата ЧЧ:ММ-ЧЧ:ММ»")
+	}
+	from, to, ok := strings.Cut(f[1], "-")
+	if !ok {
+		return ex, fmt.Errorf("интервал %q: ожидается ЧЧ:ММ-ЧЧ:ММ", f[1])
+	}
+	var err error
+	if ex.start, err = parseHHMM(from); err != nil {
+		return ex, err
+	}
+	if ex.end, err = parseHHMM(to); err != nil {
+		return ex, err
+	}
+	if ex.start == ex.end {
+		return ex, fmt.Errorf("пустой интервал %s", f[1])
+	}
+
+	if _, err := time.Parse("2006-01-02", f[0]); err == nil {
+		ex.date = f[0]
+		return ex, nil
+	}
+	if f[0] == "*" {
+		ex.days = [7]bool{true, true, true, true, true, true, true}
+		return ex, nil
+	}
+	for _, d := range strings.Split(f[0], ",") {
+		days, ok := weekdayRange(d)
+		if !ok {
+			return ex, fmt.Errorf("неизвестный день %q (mon…sun, *, или дата YYYY-MM-DD)", d)
+		}
+		for _, w := range days {
+			ex.days[w] = true
+		}
+	}
+	return ex, nil
+}
+
+// on — окно начинается в календарный день t.
+func (ex *exclusion) on(t time.Time) bool {
+	if ex.date != "" {
+		return t.Format("2006-01-02") == ex.date
+	}
+	return ex.days[t.Weekday()]
+}
+
+func (ex *exclusion) contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if ex.start < ex.end {
+		return ex.on(t) && m >= ex.start && m < ex.end
+	}
+	// через полночь: хвост вчерашнего окна или начало сегодняшнего
+	return (ex.on(t) && m >= ex.start) || (ex.on(t.AddDate(0, 0, -1)) && m < ex.end)
+}
+
+// excludedAt — окно исключения, в которое попадает now; nil — нет.
+func (a *App) excludedAt(now time.Time) *exclusion {
+	for i := range a.exclusions {
+		if a.exclusions[i].contains(now) {
+			return &a.exclusions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// C:\_Projects_Go\AcousticLog\internal\app\floor.go
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	iofs "acousticlog/internal/io"
+	"acousticlog/internal/mathx"
+	sysx "acousticlog/internal/sys"
+)
+
+const (
+	floorMinCoverage = 10 * 60 // с измерений в часе, чтобы по нему учиться
+	floorAlpha       = 0.3     // вес нового часа: фон подстраивается за несколько дней
+)
+
+// noiseFloor — фон (L90 уровней буферов) по часам суток. Каждый закончившийся час
+// подмешивается к своему слоту; слоты сохраняются в noise_floor.csv в корне
+// (только при живом захвате).
+type noiseFloor struct {
+	hours  [24]float64 // NaN — час ещё не изучен
+	key    string      // "2006-01-02 15" текущего часа
+	levels []float64
+	dur    float64
+}
+
+// addFloor — буфер в текущий час; на смене часа прошлый час идёт в обучение.
+func (a *App) addFloor(now time.Time, leq, dt float64) {
+	nf := &a.floor
+	if key := now.Format("2006-01-02 15"); key != nf.key {
+		a.learnFloor()
+		nf.key = key
+	}
+	nf.levels = append(nf.levels, leq)
+	nf.dur += dt
+}
+
+// learnFloor — L90 накопленного часа в его слот (если час достаточно покрыт измерениями).
+func (a *App) learnFloor() {
+	nf := &a.floor
+	defer func() { nf.levels, nf.dur = nf.levels[:0], 0 }()
+	if nf.dur < floorMinCoverage || len(nf.key) < 2 {
+		return
+	}
+	h, err := strconv.Atoi(nf.key[len(nf.key)-2:])
+	if err != nil {
+		return
+	}
+	l90 := mathx.Percentile(nf.levels, 90)
+	if old := nf.hours[h]; math.IsNaN(old) {
+		nf.hours[h] = l90
+	} else {
+		nf.hours[h] = old + floorAlpha*(l90-old)
+	}
+	// повтор файла и генератор учатся только в памяти: noise_floor.csv общий с живым
+	// захватом, и чужой звук испортил бы его фон по часам
+	if a.waitWAV {
+		return
+	}
+	if err := iofs.SaveNoiseFloor(a.layout.Root, nf.hours); err != nil {
+		fmt.Printf("%s[FLOOR ERROR] %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
+	}
+}
+
+// floorAt — фон для момента now: изученный слот часа, иначе скользящий L90 детектора
+// импульсов (-impulse-window); NaN — фона пока нет.
+func (a *App) floorAt(now time.Time) float64 {
+	if f := a.floor.hours[now.Hour()]; !math.IsNaN(f) {
+		return f
+	}
+	return a.baseline.level()
+}
@@ -0,0 +1,34 @@
+// C:\_Projects_Go\AcousticLog\internal\app\gaps.go
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	iofs "acousticlog/internal/io"
+)
+
+// logGap — разрыв захвата [start, end): звук за это время потерян (см. sampleClock).
+func (a *App) logGap(start, end time.Time) {
+	ms := end.Sub(start).Milliseconds()
+	atomic.AddUint64(&a.stats.CaptureGaps, 1)
+	atomic.AddUint64(&a.stats.CaptureGapMs, uint64(ms))
+	a.markOpenEvents(iofs.QualityGap)
+	a.logSystem(start, end, fmt.Sprintf("GAP_%dms", ms), iofs.QualityGap, "", "NO_WAV")
+}
+
+// logSystem — служебная строка SYSTEM в оба журнала: в sound_log — интервалом Start…End
+// (уровни пустые, detail — в колонке Detector), в sound_all — на момент start.
+// По ним видно, за какое время запись неполна.
+func (a *App) logSystem(start, end time.Time, status, quality, detail, wav string) {
+	nan := math.NaN()
+	a.enqueueCSV(a.chMainCSV, iofs.EventRow{
+		Start: start.In(a.loc), End: end.In(a.loc), Kind: status, Mode: "SYSTEM",
+		Leq: nan, Max: nan, Peak: nan, Baseline: nan, Crest: nan, Detector: detail, WAV: wav, Quality: quality,
+	}.Record())
+	a.enqueueCSV(a.chAllCSV, iofs.Row{Time: start.In(a.loc), Mode: "SYSTEM", Status: status, WAV: wav,
+		Quality: quality}.Record())
+}
@@ -14,23 +14,9 @@ func parseHHMM(s string) (int, error) {
 	return t.Hour()*60 + t.Minute(), nil
 }
 
-func (a *App) isDay(now time.Time) bool {
-	m := now.Hour()*60 + now.Minute()
-	if a.dayStart <= a.dayEnd {
-		return m >= a.dayStart && m < a.dayEnd
-	}
-	return m >= a.dayStart || m < a.dayEnd
-}
-
-func (a *App) currentLimit(now time.Time) (string, float64) {
-	if a.isDay(now) {
-		return "DAY", a.dayLimit
-	}
-	return "NIGHT", a.nightLimit
-}
-
-func nextStopAt(loc *time.Location, hhmm string) (time.Time, error) {
-	now := time.Now().In(loc)
+// nextStopAt — ближайший после now момент hhmm (в поясе now).
+func nextStopAt(now time.Time, hhmm string) (time.Time, error) {
+	loc := now.Location()
 	t, err := time.ParseInLocation("15:04", hhmm, loc)
 	if err != nil {
 		return time.Time{}, err
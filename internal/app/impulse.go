@@ -0,0 +1,67 @@
+// C:\_Projects_Go\AcousticLog\internal\app\impulse.go
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"acousticlog/internal/mathx"
+)
+
+// baselineMinSamples — сколько буферов истории нужно, прежде чем фону можно верить.
+const baselineMinSamples = 5
+
+// baseline — фон для детектора импульсов: L90 уровней буферов за последние -impulse-window.
+type baseline struct {
+	window time.Duration
+	times  []time.Time
+	levels []float64
+}
+
+// level — L90 окна; NaN, пока истории мало.
+func (b *baseline) level() float64 {
+	if len(b.levels) < baselineMinSamples {
+		return math.NaN()
+	}
+	return mathx.Percentile(b.levels, 90)
+}
+
+func (b *baseline) add(now time.Time, level float64) {
+	drop := 0
+	for drop < len(b.times) && now.Sub(b.times[drop]) >= b.window {
+		drop++
+	}
+	b.times = append(b.times[drop:], now)
+	b.levels = append(b.levels[drop:], level)
+}
+
+// crestFactor — пик к RMS буфера без коррекции, дБ.
+func crestFactor(lv levels, splOffset float64) float64 {
+	return lv.peak - (lv.dbFS + splOffset)
+}
+
+// impulseCheck — импульс относительно фона: Leq буфера выше L90 на -impulse-delta,
+// либо пик сэмпла выше L90 на -impulse-peak-delta при пик-факторе от -impulse-crest
+// (короткий удар, который в Leq буфера почти не виден). Фон берётся до этого буфера.
+func (a *App) impulseCheck(lv levels) bool {
+	base := a.baseline.level()
+	if math.IsNaN(base) {
+		return false
+	}
+	if lv.leq-base >= a.impulseDelta {
+		return true
+	}
+	return a.cfg.ImpulsePeakDelta > 0 && lv.peak-base >= a.cfg.ImpulsePeakDelta &&
+		crestFactor(lv, a.splOffset) >= a.cfg.ImpulseCrest
+}
+
+// impulseRule — параметры детектора для колонки Detector в sound_log.
+func (a *App) impulseRule() string {
+	s := fmt.Sprintf("L90/%s+%gdB", a.baseline.window, a.impulseDelta)
+	if a.cfg.ImpulsePeakDelta > 0 {
+		s += fmt.Sprintf(" | peak+%gdB crest>=%gdB", a.cfg.ImpulsePeakDelta, a.cfg.ImpulseCrest)
+	}
+	return s
+}
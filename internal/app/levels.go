@@ -0,0 +1,92 @@
+// C:\_Projects_Go\AcousticLog\internal\app\levels.go
+
+package app
+
+import (
+	"math"
+
+	"acousticlog/internal/mathx"
+)
+
+// statusSilent — статус буфера цифровой тишины в sound_all: уровень не определён.
+const statusSilent = "SILENT"
+
+// levels — уровни одного буфера (дБ, кроме dbFS — с калибровкой -spl-offset).
+type levels struct {
+	dbFS  float64 // без частотной коррекции
+	leq   float64 // энергетическое среднее буфера с коррекцией (колонка dB_SPL)
+	lf    float64
+	lfMax float64
+	ls    float64
+	lsMax float64
+	li    float64
+	liMax float64
+	peak  float64 // пик сэмпла без коррекции (LZpeak)
+
+	// clipped — сэмплов у полной шкалы (-clip-level): уровень занижен, звук искажён
+	clipped int
+
+	// bands — средний квадрат по полосам -bands (доли полной шкалы, без коррекции); nil — выключено
+	bands []float64
+
+	// level — уровень для порогов: максимум выбранной -time-weighting в буфере (EQ — leq)
+	level float64
+}
+
+// measure — прогон буфера через коррекцию и временные характеристики.
+// Возвращает ok=false для цифровой тишины (уровень не определён).
+func (a *App) measure(samples []int16) (levels, bool) {
+	rms := mathx.CalcRMSInt16(samples)
+	// фильтры прогоняем по каждому буферу, чтобы их состояние не рвалось
+	ys := a.weighting.ApplyInt16(samples)
+	tl := a.timeWeighter.Process(ys)
+	var bands []float64
+	if a.bandBank != nil {
+		bands = a.bandBank.ProcessInt16(samples)
+	}
+	rmsW := mathx.RMS(ys)
+	if rms <= 0 || rmsW <= 0 {
+		return levels{}, false
+	}
+
+	spl := func(ms float64) float64 {
+		if ms <= 0 {
+			return 0
+		}
+		return math.Max(0, mathx.PowerToDB(ms)+a.splOffset)
+	}
+	var pk, clipped int
+	for _, s := range samples {
+		v := abs(int(s))
+		pk = max(pk, v)
+		if v >= a.clipAt {
+			clipped++
+		}
+	}
+	lv := levels{
+		dbFS:    20 * math.Log10(rms),
+		leq:     math.Max(0, 20*math.Log10(rmsW)+a.splOffset),
+		lf:      spl(tl.Fast),
+		lfMax:   spl(tl.FastMax),
+		ls:      spl(tl.Slow),
+		lsMax:   spl(tl.SlowMax),
+		li:      spl(tl.Impulse),
+		liMax:   spl(tl.ImpulseMax),
+		bands:   bands,
+		clipped: clipped,
+		peak:    math.Max(0, 20*math.Log10(float64(pk)/32768.0)+a.splOffset),
+	}
+	if _, max := tl.Get(a.timeWeighting); max > 0 {
+		lv.level = spl(max)
+	} else {
+		lv.level = lv.leq
+	}
+	return lv, true
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
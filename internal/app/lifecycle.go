@@ -2,19 +2,22 @@ package app
 
 import (
 	"context"
+	"encoding/csv"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math"
 	"os"
 	"os/signal"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"sync/atomic"
 	"syscall"
 	"time"
 
-	awin "acousticlog/internal/audio/winmm"
+	"acousticlog/internal/audio"
+	"acousticlog/internal/audio/synth"
 	iofs "acousticlog/internal/io"
 	"acousticlog/internal/mathx"
 	sysx "acousticlog/internal/sys"
@@ -45,116 +48,228 @@ func Run(cfg *Config) error {
 		return fmt.Errorf("timezone %q: %w", cfg.Timezone, err)
 	}
 
+	// -log-all остался ради старых ярлыков запуска: молча его не глотаем
+	if cfg.LogAll {
+		fmt.Printf("%s[FLAG WARNING] -log-all устарел и ни на что не влияет: все измерения и так пишутся в sound_all%s\n",
+			sysx.ClrYellow, sysx.ClrReset)
+	}
+
+	clk := cfg.Clock
+	if clk == nil {
+		clk = synth.SystemClock
+	}
+
+	// Audio source; у офлайн-источников (файл) своя шкала времени
+	src, err := newSource(cfg, loc, clk)
+	if err != nil {
+		return err
+	}
+	sessionStart := clk.Now().In(loc)
+	offline, isOffline := src.(audio.Offline)
+	if isOffline {
+		sessionStart = offline.StartTime().In(loc)
+	}
+
 	// Dirs & CSV
-	root, csvDir, wavDir, err := iofs.EnsureOutDirForDate(time.Now().In(loc).Format("2006-01-02"))
+	layout := iofs.Layout{Root: cfg.OutRoot, WAV: cfg.WAVPathTemplate, CSV: cfg.CSVPathTemplate, Merged: cfg.MergedPathTemplate}
+	if layout.Root == "" {
+		layout.Root = iofs.DefaultOutRoot()
+	}
+	if err := layout.Validate(); err != nil {
+		return err
+	}
+	sessionDate := sessionStart.Format("2006-01-02")
+	root, csvDir, err := layout.EnsureForDate(sessionDate, sessionStart)
+	if err != nil {
+		return err
+	}
+	weighting, err := mathx.ParseWeighting(cfg.Weighting)
+	if err != nil {
+		return err
+	}
+	timeWeighting, err := mathx.ParseTimeWeighting(cfg.TimeWeighting)
 	if err != nil {
 		return err
 	}
-	csvHeader := iofs.DefaultCSVHeader
-	f1, w1, p1, err := iofs.CreateCSV(csvDir, "sound_log", cfg.CSVDelim, csvHeader)
+	csvHeader := iofs.CSVHeader(string(weighting))
+	eventHeader := iofs.EventCSVHeader(string(weighting), string(timeWeighting))
+	f1, w1, p1, err := iofs.CreateCSV(layout, sessionDate, "sound_log", sessionStart, cfg.CSVDelim, eventHeader)
 	if err != nil {
 		return fmt.Errorf("CSV(events): %w", err)
 	}
-	f2, w2, p2, err := iofs.CreateCSV(csvDir, "sound_all", cfg.CSVDelim, csvHeader)
+	defer f1.Close()
+	f2, w2, p2, err := iofs.CreateCSV(layout, sessionDate, "sound_all", sessionStart, cfg.CSVDelim, csvHeader)
 	if err != nil {
 		return fmt.Errorf("CSV(all): %w", err)
 	}
-	defer f1.Close()
 	defer f2.Close()
+	intervals, err := parseIntervals(cfg.StatsIntervals)
+	if err != nil {
+		return err
+	}
+	statsHeader := iofs.StatsCSVHeader(string(weighting), string(timeWeighting))
+	f3, w3, p3, err := iofs.CreateCSV(layout, sessionDate, "sound_stats", sessionStart, cfg.CSVDelim, statsHeader)
+	if err != nil {
+		return fmt.Errorf("CSV(stats): %w", err)
+	}
+	defer f3.Close()
 
-	// Audio init
-	fmtx := awin.WaveFormatPCM1ch16(cfg.SampleRate)
-	h, err := awin.WaveInOpen(awin.WAVE_MAPPER, &fmtx)
+	bandMode, err := mathx.ParseBands(cfg.Bands)
+	if err != nil {
+		return err
+	}
+	bandInterval, err := parseInterval("band-interval", cfg.BandInterval)
 	if err != nil {
-		return fmt.Errorf("waveInOpen: %w", err)
+		return err
+	}
+	var (
+		bandHeader []string
+		f4         *os.File
+		w4         *csv.Writer
+		p4         string
+	)
+	if bandMode != mathx.BandsOff {
+		bandHeader = iofs.BandCSVHeader(mathx.BandLabels(bandMode))
+		f4, w4, p4, err = iofs.CreateCSV(layout, sessionDate, "sound_bands", sessionStart, cfg.CSVDelim, bandHeader)
+		if err != nil {
+			return fmt.Errorf("CSV(bands): %w", err)
+		}
+		defer f4.Close()
+	}
+	bandCheck, err := newBandCheck(cfg, bandMode)
+	if err != nil {
+		return err
 	}
 
-	bytesPerMs := int(fmtx.NAvgBytesPerSec) / 1000
-	size := bytesPerMs * cfg.BufferMs
-	if size < 512 {
-		size = 512
+	// Audio init
+	if err := src.Open(); err != nil {
+		return fmt.Errorf("audio source %q: %w", cfg.Source, err)
 	}
-	bufs := make([]*buffer, 3)
-	for i := 0; i < 3; i++ {
-		mem := make([]byte, size)
-		hdr := WAVEHDR{LpData: &mem[0], DwBufferLength: uint32(len(mem))}
-		bufs[i] = &buffer{mem: mem, hdr: hdr}
+	// частота WAV-файла известна только после Open
+	var bandBank *mathx.BandFilterBank
+	if bandMode != mathx.BandsOff {
+		bandBank = mathx.NewBandFilterBank(bandMode, src.Format().SampleRate)
 	}
 
 	app := &App{
-		cfg:          cfg,
-		Handle:       h,
-		Fmt:          fmtx,
-		Bufs:         bufs,
-		csvFile:      f1,
-		csvWriter:    w1,
-		csvPath:      p1,
-		csvAllFile:   f2,
-		csvAllWriter: w2,
-		csvAllPath:   p2,
-		outDirRoot:   root,
-		outDirCSV:    csvDir,
-		outDirWAV:    wavDir,
-		diskWarnMB:   cfg.DiskWarnMB,
-		diskStopMB:   cfg.DiskStopMB,
-		loc:          loc,
-		splOffset:    cfg.SPLOffset,
-		dayLimit:     cfg.DayLimit,
-		nightLimit:   cfg.NightLimit,
-		bufMs:        cfg.BufferMs,
-		quiet:        cfg.QuietMode,
-		nearMargin:   3.0,
-		logAll:       cfg.LogAll,
-		csvDelim:     cfg.CSVDelim,
-		impulseDelta: cfg.ImpulseDelta,
-		liveNoClear:  cfg.LiveNoClear,
-		maxLines:     cfg.LiveLines,
-		liveWavDepth: cfg.LiveWavDepth,
-		chMainCSV:    make(chan []string, 256),
-		chAllCSV:     make(chan []string, 512),
-		chWAV:        make(chan wavTask, 256),
-		currentDate:  time.Now().In(loc).Format("2006-01-02"),
-	}
-
-	// day start/end
-	if v, err := parseHHMM(cfg.DayStartHHMM); err == nil {
-		app.dayStart = v
-	} else {
-		return err
-	}
-	if v, err := parseHHMM(cfg.DayEndHHMM); err == nil {
-		app.dayEnd = v
-	} else {
+		weighting:     mathx.NewWeightingFilter(weighting, src.Format().SampleRate),
+		timeWeighter:  mathx.NewTimeWeighter(src.Format().SampleRate),
+		timeWeighting: timeWeighting,
+		cfg:           cfg,
+		src:           src,
+		wall:          clk,
+		format:        src.Format(),
+		csvFile:       f1,
+		csvWriter:     w1,
+		csvPath:       p1,
+		csvAllFile:    f2,
+		csvAllWriter:  w2,
+		csvAllPath:    p2,
+		statsFile:     f3,
+		statsWriter:   w3,
+		statsPath:     p3,
+		statsHeader:   statsHeader,
+		bandFile:      f4,
+		bandWriter:    w4,
+		bandPath:      p4,
+		bandHeader:    bandHeader,
+		bandBank:      bandBank,
+		bandCheck:     bandCheck,
+		bandInterval:  bandInterval,
+		layout:        layout,
+		outDirRoot:    root,
+		outDirCSV:     csvDir,
+		diskWarnMB:    cfg.DiskWarnMB,
+		diskStopMB:    cfg.DiskStopMB,
+		loc:           loc,
+		splOffset:     cfg.SPLOffset,
+		clipAt:        int(32767 * math.Pow(10, cfg.ClipLevel/20)),
+		bufMs:         cfg.BufferMs,
+		quiet:         cfg.QuietMode,
+		nearMargin:    3.0,
+		csvDelim:      cfg.CSVDelim,
+		csvHeader:     csvHeader,
+		eventHeader:   eventHeader,
+		impulseDelta:  cfg.ImpulseDelta,
+		liveNoClear:   cfg.LiveNoClear,
+		maxLines:      cfg.LiveLines,
+		liveWavDepth:  cfg.LiveWavDepth,
+		chMainCSV:     make(chan []string, 256),
+		chAllCSV:      make(chan []string, 512),
+		chWAV:         make(chan wavTask, 256),
+		chStatsCSV:    make(chan []string, 64),
+		chBandCSV:     make(chan []string, 256),
+		currentDate:   sessionDate,
+		waitWAV:       isOffline,
+	}
+
+	if !isOffline {
+		app.clock = &sampleClock{rate: app.format.SampleRate, resync: cfg.ClockResync, gapMin: cfg.GapMin}
+	}
+	app.preRoll.size = app.bytesFor(cfg.PreRoll)
+	app.baseline.window = cfg.ImpulseWindow
+	if app.floor.hours, err = iofs.LoadNoiseFloor(layout.Root); err != nil {
+		fmt.Printf("%s[FLOOR WARNING] %v — фон изучается заново%s\n", sysx.ClrYellow, err, sysx.ClrReset)
+	}
+
+	for _, d := range intervals {
+		app.intervals = append(app.intervals, &statsInterval{d: d, label: intervalLabel(d)})
+	}
+
+	// периоды суток и их пороги
+	if app.periods, err = parsePeriods(cfg); err != nil {
+		_ = src.Close()
 		return err
 	}
-
-	// Prepare buffers
-	for _, b := range app.Bufs {
-		if err := awin.WaveInPrepareHeader(app.Handle, &b.hdr); err != nil {
-			return fmt.Errorf("prepare: %w", err)
+	// недельное расписание и праздники — поверх -periods
+	allPeriods := app.periods
+	if cfg.Schedule != "" || cfg.Holidays != "" {
+		if app.schedule, err = parseSchedule(cfg.Schedule, app.periods); err == nil {
+			err = app.schedule.parseHolidays(cfg.Holidays)
+		}
+		if err != nil {
+			_ = src.Close()
+			return err
 		}
-		if err := awin.WaveInAddBuffer(app.Handle, &b.hdr); err != nil {
-			return fmt.Errorf("addbuf: %w", err)
+		for _, ps := range app.schedule.profiles() {
+			allPeriods = append(allPeriods, ps...)
 		}
 	}
 
-	// Start audio
-	if err := awin.WaveInStart(app.Handle); err != nil {
-		return fmt.Errorf("start: %w", err)
+	// правила тревог; виды для -merge-kinds — встроенные или имена правил
+	if app.rules, err = parseRules(cfg.Rules, weighting, allPeriods); err != nil {
+		_ = src.Close()
+		return err
+	}
+	app.rules.init(src.Format().SampleRate)
+	if app.exclusions, err = parseExclusions(cfg.Exclude, cfg.ExcludeFile); err != nil {
+		_ = src.Close()
+		return err
+	}
+	for _, k := range cfg.MergeKinds {
+		if !isBuiltinKind(k) && !app.rules.has(k) {
+			_ = src.Close()
+			return fmt.Errorf("merge-kinds: неизвестный вид события %q", k)
+		}
 	}
 
+	// Capture
+	captureCtx, stopCapture := context.WithCancel(context.Background())
+	app.stopCapture = stopCapture
+	frames := app.startCapture(captureCtx)
+
 	// Workers
 	app.startWorkers()
 
 	// Резюмирование незавершённых мерджей при старте
-	ResumePendingMerges(context.Background(), app.cfg, app.outDirWAV)
+	ResumePendingMerges(context.Background(), app.cfg, app.layout, app.currentDate)
 
 	// UI header
 	if !app.quiet {
 		app.printLiveHeader()
 	} else {
-		fmt.Printf("Мониторинг… CSV(events) → %s | CSV(all) → %s | День %.1f / Ночь %.1f дБ | импульс ≥ %.1f дБ\n",
-			app.csvPath, app.csvAllPath, app.dayLimit, app.nightLimit, app.impulseDelta)
+		fmt.Printf("Мониторинг… CSV(events) → %s | CSV(all) → %s | %s дБ | импульс ≥ %.1f дБ\n",
+			app.csvPath, app.csvAllPath, app.periodsSummary(), app.impulseDelta)
 	}
 
 	// Disk ticker
@@ -162,21 +277,19 @@ func Run(cfg *Config) error {
 	diskCheckTicker := time.NewTicker(DefaultDiskCheckInterval)
 	defer diskCheckTicker.Stop()
 
-	// Часовой тикер + фиксация текущего часа
-	lastHour := time.Now().In(app.loc).Format("15")
-	hourTicker := time.NewTicker(10 * time.Second)
-	defer hourTicker.Stop()
+	// Смена часа определяется по времени кадров (а не по стенным часам),
+	// чтобы склейка работала и для источников с собственной шкалой времени.
+	lastHour := ""
+	var lastWhen time.Time
 
 	// Сводка мерджей за сессию (по часам)
 	var mergedHours []mergeInfo
 
-	// Auto-stop timer (только если не /auto)
-	var timer *time.Timer
-	if !cfg.AutoMode {
-		if dl, err := nextStopAt(loc, cfg.StopAtHHMM); err == nil {
-			timer = new(time.Timer)
-			*timer = *time.NewTimer(time.Until(dl))
-			defer timer.Stop()
+	// Auto-stop timer (только если не /auto и источник живой)
+	var stopC <-chan time.Time
+	if !cfg.AutoMode && !isOffline {
+		if dl, err := nextStopAt(clk.Now().In(loc), cfg.StopAtHHMM); err == nil {
+			stopC = clk.After(dl.Sub(clk.Now()))
 		}
 	}
 
@@ -184,108 +297,78 @@ func Run(cfg *Config) error {
 	intCh := make(chan os.Signal, 1)
 	signal.Notify(intCh, os.Interrupt, syscall.SIGTERM)
 
-	// Loop
-	tick := time.NewTicker(20 * time.Millisecond)
-	defer tick.Stop()
-
 loop:
 	for {
 		select {
-		case <-tick.C:
-			for _, b := range app.Bufs {
-				if (b.hdr.DwFlags & awin.WHDR_DONE) != 0 {
-					app.process(b)
-					b.hdr.DwFlags &^= awin.WHDR_DONE
-					b.hdr.DwBytesRecorded = 0
-					_ = awin.WaveInAddBuffer(app.Handle, &b.hdr)
+		case c, ok := <-frames:
+			if !ok {
+				if errors.Is(app.captureErr, io.EOF) {
+					fmt.Println("\nИсточник звука исчерпан — завершение…")
+				} else {
+					fmt.Printf("\n%s[AUDIO ERROR] %v%s\n", sysx.ClrRed, app.captureErr, sysx.ClrReset)
 				}
+				break loop
+			}
+			if c.device != "" {
+				app.deviceEvent(c)
+				continue
+			}
+			fr := c.fr
+			var shift time.Duration
+			kind := shiftNone
+			if app.clock != nil {
+				fr.When, shift, kind = app.clock.stamp(fr.When, len(fr.PCM)/2)
+			}
+			app.process(fr)
+			switch kind {
+			case shiftResync:
+				app.logClockDrift(fr.When, shift)
+			case shiftGap:
+				app.logGap(fr.When.Add(-shift), fr.When)
 			}
 
+			// Автосклейка завершившегося часа + сводка
+			now := fr.When.In(app.loc)
+			h := now.Format("15")
+			if lastHour != "" && h != lastHour && !app.cfg.NoHourlyMerge {
+				// Папка именно того дня, к которому относится завершившийся час
+				mergedHours = append(mergedHours, app.mergeHourOf(lastWhen))
+			}
+			lastHour, lastWhen = h, now
+
 		case <-diskCheckTicker.C:
 			app.updateDiskStatus()
 			if app.diskFreeMB < app.diskStopMB {
-				now := time.Now().In(app.loc)
-				record := []string{
-					now.Format("2006-01-02 15:04:05.000"),
-					"SYSTEM", "0.00", "0.00", "0.0",
-					fmt.Sprintf("FATAL_DISK_SPACE_LEFT_%.1fMB", float64(app.diskFreeMB)),
-					"NO_WAV",
-				}
-				_ = iofs.SafeWrite(app.csvWriter, record)
-				_ = iofs.SafeWrite(app.csvAllWriter, record)
+				now := app.wall.Now()
+				app.logSystem(now, now, fmt.Sprintf("FATAL_DISK_SPACE_LEFT_%.1fMB", float64(app.diskFreeMB)),
+					iofs.QualityShutdown, "", "NO_WAV")
 				fmt.Printf("\n%s[FATAL ERROR] КРИТИЧЕСКИ МАЛО МЕСТА (%.1f МБ). Аварийное завершение...%s\n",
 					sysx.ClrRed, float64(app.diskFreeMB), sysx.ClrReset)
 				break loop
 			}
 
-		// Автосклейка завершившегося часа + сводка
-		case <-hourTicker.C:
-			now := time.Now().In(app.loc)
-			h := now.Format("15")
-			if h != lastHour {
-				prev := lastHour
-				lastHour = h
-				if !app.cfg.NoHourlyMerge {
-					// Определяем переменные заранее, чтобы они были видны во всём блоке...
-					var (
-						out string
-						n   int
-						err error
-					)
-
-					// Всегда «только что завершившийся» час...
-					mergeTime := now.Add(-1 * time.Hour)
-					dayStr := mergeTime.Format("2006-01-02")
-
-					// Папка именно того дня, к которому относится prev...
-					_, _, dayWavDir, errDir := iofs.EnsureOutDirForDate(dayStr)
-					if errDir != nil {
-						mergedHours = append(mergedHours, mergeInfo{
-							Hour: prev, Err: fmt.Errorf("ensure out dir for %s: %w", dayStr, errDir),
-						})
-					} else {
-						out, n, err = StartHourlyMerge(context.Background(), app.cfg, dayWavDir, prev)
-						mergedHours = append(mergedHours, mergeInfo{
-							Hour: prev, OutPath: out, Clips: n, Err: err,
-						})
-					}
-				}
-			}
-
 		case <-intCh:
 			fmt.Println("\nCtrl+C — остановка…")
 			break loop
 
-		default:
-			if timer != nil {
-				select {
-				case <-timer.C:
-					fmt.Println("\nДостигнуто время авто-остановки — завершение…")
-					timer = nil
-					break loop
-				default:
-				}
-			}
+		case <-stopC:
+			fmt.Println("\nДостигнуто время авто-остановки — завершение…")
+			break loop
 		}
 	}
 
+	// незакрытые события — в CSV и WAV до финальной склейки (конец оборван остановкой)
+	app.markOpenEvents(iofs.QualityShutdown)
+	for _, t := range app.tracks() {
+		app.closeEvent(t)
+	}
+
 	// Синхронный мердж текущего часа на завершение + сводка
 	if !app.cfg.NoHourlyMerge {
-		now := time.Now().In(app.loc)
-		hh := now.Format("15")
-
-		// Жёстко берём каталог по текущей дате завершения; учитываем ошибку
-		_, _, dayWavDir, errDir := iofs.EnsureOutDirForDate(now.Format("2006-01-02"))
-		if errDir != nil {
-			mergedHours = append(mergedHours, mergeInfo{
-				Hour: hh, Err: fmt.Errorf("ensure out dir for %s: %w", now.Format("2006-01-02"), errDir),
-			})
-		} else {
-			out, n, err := StartHourlyMerge(context.Background(), app.cfg, dayWavDir, hh)
-			mergedHours = append(mergedHours, mergeInfo{
-				Hour: hh, OutPath: out, Clips: n, Err: err,
-			})
+		if lastWhen.IsZero() {
+			lastWhen = app.wall.Now().In(app.loc)
 		}
+		mergedHours = append(mergedHours, app.mergeHourOf(lastWhen))
 	}
 
 	a := app
@@ -300,7 +383,9 @@ func (a *App) startWorkers() {
 	go func() {
 		defer a.wg.Done()
 		for rec := range a.chMainCSV {
-			if err := iofs.SafeWrite(a.csvWriter, rec); err != nil {
+			err := iofs.SafeWrite(a.csvWriter, rec)
+			a.csvPending.Done()
+			if err != nil {
 				fmt.Printf("%s[CSV write error] %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
 				atomic.AddUint64(&a.stats.CSVErrors, 1)
 			}
@@ -312,34 +397,89 @@ func (a *App) startWorkers() {
 	go func() {
 		defer a.wg.Done()
 		for rec := range a.chAllCSV {
-			if err := iofs.SafeWrite(a.csvAllWriter, rec); err != nil {
+			err := iofs.SafeWrite(a.csvAllWriter, rec)
+			a.csvPending.Done()
+			if err != nil {
 				fmt.Printf("%s[CSV flush error] %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
 				atomic.AddUint64(&a.stats.CSVErrors, 1)
 			}
 		}
 	}()
 
+	// Stats CSV writer
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		for rec := range a.chStatsCSV {
+			err := iofs.SafeWrite(a.statsWriter, rec)
+			a.csvPending.Done()
+			if err != nil {
+				fmt.Printf("%s[CSV write error] %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
+				atomic.AddUint64(&a.stats.CSVErrors, 1)
+			}
+		}
+	}()
+
+	// Bands CSV writer
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		for rec := range a.chBandCSV {
+			err := iofs.SafeWrite(a.bandWriter, rec)
+			a.csvPending.Done()
+			if err != nil {
+				fmt.Printf("%s[CSV write error] %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
+				atomic.AddUint64(&a.stats.CSVErrors, 1)
+			}
+		}
+	}()
+
 	// WAV workers (3)
 	for i := 0; i < 3; i++ {
 		a.wg.Add(1)
 		go func() {
 			defer a.wg.Done()
 			for task := range a.chWAV {
-				path, err := iofs.SaveWAVKind(a.outDirWAV, task.when, task.rate, task.pcm, task.kind)
-				if err != nil {
-					fmt.Printf("%s[WAV error] %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
-					atomic.AddUint64(&a.stats.WAVErrors, 1)
-					continue
-				}
-				atomic.AddUint64(&a.stats.WAVFilesSaved, 1)
-				if task.after != nil {
-					task.after(path)
-				}
+				a.saveWAV(task)
 			}
 		}()
 	}
 }
 
+// saveWAV — клип, его спектрограмма и after. Задача считается выполненной (wavPending)
+// только после всего этого: склейка часа не должна начаться, пока PNG клипа ещё пишется.
+func (a *App) saveWAV(task wavTask) {
+	defer a.wavPending.Done()
+	path, err := iofs.SaveWAVKind(a.layout, task.when, task.rate, task.pcm, task.kind)
+	if err != nil {
+		fmt.Printf("%s[WAV error] %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
+		atomic.AddUint64(&a.stats.WAVErrors, 1)
+		return
+	}
+	atomic.AddUint64(&a.stats.WAVFilesSaved, 1)
+	if !a.cfg.NoSpectrogram {
+		if _, err := iofs.SpectrogramPNG(path, task.rate, task.pcm, task.when); err != nil {
+			fmt.Printf("%s[PNG error] %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
+		}
+	}
+	if task.after != nil {
+		task.after(path)
+	}
+}
+
+// enqueueCSV — строка в очередь писателя CSV. Учитывается в csvPending, чтобы
+// ротация по дате дождалась записи строк прошлого дня в прошлые файлы.
+func (a *App) enqueueCSV(ch chan []string, rec []string) {
+	a.csvPending.Add(1)
+	select {
+	case ch <- rec:
+	default:
+		// писатель не успевает — ждём его, а цикл тем временем отстаёт от захвата
+		atomic.AddUint64(&a.stats.CSVQueueFull, 1)
+		ch <- rec
+	}
+}
+
 func (a *App) updateDiskStatus() {
 	freeMB, err := sysx.GetFreeDiskSpaceMB(a.outDirRoot)
 	if err != nil {
@@ -351,38 +491,73 @@ func (a *App) updateDiskStatus() {
 	atomic.AddUint64(&a.stats.DiskChecks, 1)
 }
 
-func (a *App) process(b *buffer) {
+func (a *App) process(fr audio.Frame) {
 	if a.isShutting.Load() {
 		return
 	}
 	atomic.AddUint64(&a.stats.BuffersProcessed, 1)
 
-	n := int(b.hdr.DwBytesRecorded)
-	if n <= 0 || n > len(b.mem) {
+	raw := fr.PCM
+	if len(raw) == 0 {
 		return
 	}
-	raw := b.mem[:n]
 	samples := mathx.BytesToInt16LE(raw)
 	if len(samples) == 0 {
 		return
 	}
-	rms := mathx.CalcRMSInt16(samples)
-	if rms <= 0 {
-		return
-	}
+	lv, ok := a.measure(samples)
 
-	dbFS := 20 * math.Log10(rms)
-	dbSPL := 20*math.Log10(rms) + a.splOffset
-	if dbSPL < 0 {
-		dbSPL = 0
+	now := fr.When.In(a.loc)
+	dt := float64(len(samples)) / float64(a.format.SampleRate)
+	a.flushIntervals(now, false)
+	a.flushBands(now, false)
+	// события закрываются до ротации — их строки относятся к прошлому дню/часу
+	for _, t := range a.tracks() {
+		a.expireEvent(t, now)
 	}
-
-	now := time.Now().In(a.loc)
 	a.rotateIfDateChanged(now)
+	if !ok {
+		a.processSilent(now, raw, dt)
+		return
+	}
+	dbSPL := lv.leq
+	if lv.clipped > 0 {
+		atomic.AddUint64(&a.stats.ClippedBuffers, 1)
+	}
+	// окно исключения (-exclude): буфер только в sound_all, в статистику и события не идёт
+	excluded := a.excludedAt(now)
+	if excluded == nil && a.inExclusion {
+		// окно кончилось: превышение и скользящие окна правил набираются заново,
+		// без своего шума и без дыры на месте окна
+		a.exceed = exceedGate{}
+		a.rules.resetWindows()
+	}
+	a.inExclusion = excluded != nil
+	if excluded == nil {
+		a.addIntervals(now, lv, dt)
+		a.addBands(now, lv.bands, dt)
+		a.addDaily(now, lv.leq, dt)
+	}
+	a.lastFrameEnd = now.Add(time.Duration(dt * float64(time.Second)))
 	mode, lim := a.currentLimit(now)
 
-	exceeded := dbSPL >= lim
-	impulse := a.prevInit && (dbSPL-a.prevDbSPL) >= a.impulseDelta
+	// порог сравниваем с уровнем по -time-weighting (по умолчанию LAFmax буфера)
+	// с гистерезисом и минимальной длительностью (exceed.go),
+	// импульс — относительно фона L90 (impulse.go)
+	gate := a.exceedStep(now, lv.level, lim, dt)
+	exceeded := gate == gateOn
+	impulse := a.impulseCheck(lv)
+	// пороги по полосам и НЧ-детектор (ночные пороги — для ночных периодов)
+	bandKind := a.bandStatus(lv, a.periodAt(now).kind == kindNight)
+
+	// правила -rules: свои тревоги, независимо от встроенных детекторов
+	var alarms map[*rule]bool
+	if excluded == nil {
+		alarms = a.evalRules(now, samples, lv, dt, map[string]bool{
+			iofs.EventKindExceeded: exceeded, iofs.EventKindImpulse: impulse,
+			iofs.EventKindBand: bandKind == iofs.EventKindBand, iofs.EventKindLowFreq: bandKind == iofs.EventKindLowFreq,
+		})
+	}
 
 	color := sysx.ClrGray
 	status := "OK"
@@ -393,33 +568,68 @@ func (a *App) process(b *buffer) {
 	case impulse:
 		color = sysx.ClrCyan
 		status = "IMPULSE"
-	case dbSPL >= lim-a.nearMargin:
+	case bandKind != "":
+		color = sysx.ClrMagenta
+		status = bandKind
+	case gate == gatePending || lv.level >= lim-a.nearMargin:
 		color = sysx.ClrYellow
 		status = "NEAR"
 	}
-	// Определяем папку события для WAV
+	if status == "OK" || status == "NEAR" {
+		for _, r := range a.rules.list {
+			if alarms[r] {
+				color, status = sysx.ClrBlue, r.name
+				break
+			}
+		}
+	}
+	// буфер сработал — открывает или продлевает событие
 	kind := ""
 	switch status {
-	case "EXCEEDED":
-		kind = "EXCEEDED"
-	case "IMPULSE":
-		kind = "IMPULSE"
+	case iofs.EventKindExceeded, iofs.EventKindImpulse, iofs.EventKindBand, iofs.EventKindLowFreq:
+		kind = status
+	}
+	if excluded != nil && kind != "" {
+		color, status, kind = sysx.ClrGray, statusExcluded, ""
 	}
+	event := kind != ""
 
 	freeMB := a.diskFreeMB
 	canSaveWAV := freeMB > a.diskWarnMB
-
 	var wavFilename string
-	if exceeded || impulse {
-		if canSaveWAV {
-			wavFilename = filepath.Join(a.outDirWAV, now.Format("15"), kind, fmt.Sprintf("noise_%s.wav", now.Format("20060102_150405.000")))
-		} else {
-			wavFilename = fmt.Sprintf("DISK_LOW_SPACE_%.1fMB", float64(freeMB))
+	if excluded != nil {
+		// свой шум не должен попасть ни в клип, ни в pre-roll следующего события
+		for _, t := range a.tracks() {
+			a.closeEvent(t)
+		}
+		a.preRoll.keep(0)
+	} else {
+		// до -exceed-min событие открывается условно и отбрасывается, если превышение не набралось
+		tentative := !event && gate == gatePending
+		if gate == gateOff {
+			a.dropTentative()
+		}
+		if tentative {
+			kind = iofs.EventKindExceeded
+		}
+		wavFilename = a.trackEvent(&a.events, now, kind, mode, lim, lv, dt, raw, tentative)
+		for _, r := range a.rules.list {
+			k := ""
+			if alarms[r] {
+				k = r.name
+			}
+			if w := a.trackEvent(&r.track, now, k, mode, lim, lv, dt, raw, false); wavFilename == "" {
+				wavFilename = w
+			}
 		}
+		a.preRoll.push(raw)
 	}
 
 	if !a.quiet {
 		shortWav := ""
+		if excluded != nil && excluded.label != "" {
+			shortWav = excluded.label
+		}
 		if wavFilename != "" {
 			if strings.HasPrefix(wavFilename, "DISK_LOW_SPACE") {
 				shortWav = sysx.ClrYellow + "DISK LOW" + sysx.ClrReset
@@ -427,8 +637,11 @@ func (a *App) process(b *buffer) {
 				shortWav = shortenPath(wavFilename, a.liveWavDepth)
 			}
 		}
-		fmt.Printf("%s%-23s  %-5s %7.1f  %6.1f  %5.1f  %-7s %s%s\n",
-			color, now.Format("2006-01-02 15:04:05.000"), mode, dbFS, dbSPL, lim, status, shortWav, sysx.ClrReset)
+		if lv.clipped > 0 {
+			shortWav = sysx.ClrRed + iofs.QualityClipped + sysx.ClrReset + " " + shortWav
+		}
+		fmt.Printf("%s%-23s  %-*s %7.1f  %6.1f  %6.1f  %5.1f  %-7s %s%s\n",
+			color, now.Format("2006-01-02 15:04:05.000"), a.modeWidth(), mode, lv.dbFS, dbSPL, lv.level, lim, status, shortWav, sysx.ClrReset)
 
 		if !a.liveNoClear {
 			a.linesPrinted++
@@ -437,34 +650,76 @@ func (a *App) process(b *buffer) {
 			}
 		}
 
-		if !canSaveWAV && (exceeded || impulse) {
+		if !canSaveWAV && event {
 			fmt.Printf("%s[DISK WARNING] МЕСТО ЗАКАНЧИВАЕТСЯ: %.1f МБ. WAV-файлы НЕ ЗАПИСАНЫ.%s\n",
 				sysx.ClrYellow, float64(freeMB), sysx.ClrReset)
 		}
 	}
 
-	ts := now.Format("2006-01-02 15:04:05.000")
-	row := []string{ts, mode,
-		strconv.FormatFloat(dbFS, 'f', 2, 64), strconv.FormatFloat(dbSPL, 'f', 2, 64),
-		strconv.FormatFloat(lim, 'f', 1, 64), status, wavFilename}
+	quality := iofs.QualityOK
+	if lv.clipped > 0 {
+		quality = iofs.QualityClipped
+	}
+	row := iofs.Row{
+		Time: now, Mode: mode, DBFS: lv.dbFS, DBSPL: dbSPL,
+		LF: lv.lf, LFMax: lv.lfMax, LS: lv.ls, LSMax: lv.lsMax, LI: lv.li, LIMax: lv.liMax,
+		Limit: lim, Status: status, WAV: wavFilename, Quality: quality,
+	}.Record()
 
-	a.chAllCSV <- row
+	// sound_all — каждый буфер; sound_log и клип — по событию целиком (см. events.go)
+	a.enqueueCSV(a.chAllCSV, row)
 	atomic.AddUint64(&a.stats.CSVAllWritten, 1)
-	if exceeded || impulse || a.logAll {
-		a.chMainCSV <- row
-		atomic.AddUint64(&a.stats.CSVEventsWritten, 1)
+
+	if excluded == nil {
+		a.baseline.add(now, dbSPL)
+		a.addFloor(now, dbSPL, dt)
 	}
+}
 
-	if (exceeded || impulse) && canSaveWAV {
-		select {
-		case a.chWAV <- wavTask{when: now, rate: int(a.Fmt.NSamplesPerSec), pcm: append([]byte(nil), raw...), kind: kind}:
-		default:
-			// дроп без блокировки
+// processSilent — буфер цифровой тишины: уровня нет, поэтому в статистику и детекторы
+// он не идёт, но звук продолжает открытые события (клип без дыры), а в sound_all —
+// строка SILENT с пустыми уровнями: по журналу видно, что источник работал.
+func (a *App) processSilent(now time.Time, raw []byte, dt float64) {
+	a.lastFrameEnd = now.Add(time.Duration(dt * float64(time.Second)))
+	mode, lim := a.currentLimit(now)
+	wav := ""
+	if excluded := a.excludedAt(now); excluded != nil {
+		a.inExclusion = true
+		for _, t := range a.tracks() {
+			a.closeEvent(t)
+		}
+		a.preRoll.keep(0)
+	} else {
+		for _, t := range a.tracks() {
+			if t.ev != nil && t.ev.save {
+				t.ev.pcm = append(t.ev.pcm, raw...)
+			}
+		}
+		a.preRoll.push(raw)
+		if ev := a.events.ev; ev != nil && !ev.tentative {
+			wav = ev.wav
+		}
+	}
+
+	if !a.quiet {
+		fmt.Printf("%s%-23s  %-*s %7s  %6s  %6s  %5.1f  %-7s %s%s\n",
+			sysx.ClrGray, now.Format("2006-01-02 15:04:05.000"), a.modeWidth(), mode, "-", "-", "-", lim, statusSilent,
+			shortenPath(wav, a.liveWavDepth), sysx.ClrReset)
+		if !a.liveNoClear {
+			a.linesPrinted++
+			if a.linesPrinted >= a.maxLines {
+				a.printLiveHeader()
+			}
 		}
 	}
 
-	a.prevDbSPL = dbSPL
-	a.prevInit = true
+	nan := math.NaN()
+	a.enqueueCSV(a.chAllCSV, iofs.Row{
+		Time: now, Mode: mode, DBFS: nan, DBSPL: nan,
+		LF: nan, LFMax: nan, LS: nan, LSMax: nan, LI: nan, LIMax: nan,
+		Limit: lim, Status: statusSilent, WAV: wav, Quality: iofs.QualityOK,
+	}.Record())
+	atomic.AddUint64(&a.stats.CSVAllWritten, 1)
 }
 
 // shutdownWithStats — завершение + расширенная сводка мерджей по часам (кол-во клипов и размер).
@@ -474,14 +729,25 @@ func (a *App) shutdownWithStats(timeout time.Duration, mergedHours []mergeInfo)
 	}
 	fmt.Printf("\n%s🔄 Завершение работы...%s\n", sysx.ClrYellow, sysx.ClrReset)
 
-	_ = awin.WaveInStop(a.Handle)
-	for _, b := range a.Bufs {
-		_ = awin.WaveInUnprepareHeader(a.Handle, &b.hdr)
+	// Останов захвата: сначала горутина чтения, затем само устройство/источник
+	if a.stopCapture != nil {
+		a.stopCapture()
+		<-a.captureDone
+	}
+	if err := a.src.Close(); err != nil {
+		fmt.Printf("%s[AUDIO ERROR] close: %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
 	}
-	_ = awin.WaveInClose(a.Handle)
+
+	// неполные окна статистики — тоже в CSV (Measured_s покажет покрытие)
+	a.flushIntervals(a.lastFrameEnd, true)
+	a.flushBands(a.lastFrameEnd, true)
+	a.writeDaily(a.currentDate)
+	a.learnFloor()
 
 	close(a.chMainCSV)
 	close(a.chAllCSV)
+	close(a.chStatsCSV)
+	close(a.chBandCSV)
 	close(a.chWAV)
 
 	done := make(chan struct{})
@@ -500,10 +766,19 @@ func (a *App) shutdownWithStats(timeout time.Duration, mergedHours []mergeInfo)
 	if a.csvAllWriter != nil {
 		a.csvAllWriter.Flush()
 	}
+	if a.statsWriter != nil {
+		a.statsWriter.Flush()
+	}
+	if a.bandWriter != nil {
+		a.bandWriter.Flush()
+	}
 
 	// Базовая статистика
 	a.printStats()
-	fmt.Printf("📄 CSV(events): %s\n📄 CSV(all):    %s\n", a.csvPath, a.csvAllPath)
+	fmt.Printf("📄 CSV(events): %s\n📄 CSV(all):    %s\n📄 CSV(stats):  %s\n", a.csvPath, a.csvAllPath, a.statsPath)
+	if a.bandPath != "" {
+		fmt.Printf("📄 CSV(bands):  %s\n", a.bandPath)
+	}
 
 	// Новая секция: сводка по часовым мерджам (по ходу сессии + финальный)
 	if len(mergedHours) > 0 {
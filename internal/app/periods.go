@@ -0,0 +1,320 @@
+// C:\_Projects_Go\AcousticLog\internal\app\periods.go
+
+package app
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	iofs "acousticlog/internal/io"
+	"acousticlog/internal/mathx"
+	sysx "acousticlog/internal/sys"
+)
+
+// periodKind — к какому из периодов Lden относится интервал суток.
+type periodKind int
+
+const (
+	kindDay periodKind = iota
+	kindEvening
+	kindNight
+)
+
+var kindNames = [...]string{"day", "evening", "night"}
+
+// штрафы по видам периодов (дБ): Lden — +5 вечер, +10 ночь; Ldn — только ночь
+var (
+	denPenalty = []float64{0, 5, 10}
+	dnPenalty  = []float64{0, 0, 10}
+)
+
+// period — именованный период суток: действует с start (минуты от полуночи)
+// до начала следующего, со своим порогом.
+type period struct {
+	name  string
+	start int
+	limit float64
+	kind  periodKind
+}
+
+// parsePeriods — "DAY=07:00/55,EVENING=19:00/50,NIGHT=23:00/45[/night]".
+// Вид периода — третье поле или по имени (…EVENING…, …NIGHT…), иначе day.
+// Пустая строка — два периода из -day-start/-day-end/-day-limit/-night-limit.
+func parsePeriods(cfg *Config) ([]period, error) {
+	if strings.TrimSpace(cfg.Periods) == "" {
+		ds, err := parseHHMM(cfg.DayStartHHMM)
+		if err != nil {
+			return nil, fmt.Errorf("day-start: %w", err)
+		}
+		de, err := parseHHMM(cfg.DayEndHHMM)
+		if err != nil {
+			return nil, fmt.Errorf("day-end: %w", err)
+		}
+		return sortPeriods([]period{
+			{name: "DAY", start: ds, limit: cfg.DayLimit, kind: kindDay},
+			{name: "NIGHT", start: de, limit: cfg.NightLimit, kind: kindNight},
+		})
+	}
+	return parsePeriodList(cfg.Periods)
+}
+
+// parsePeriodList — список периодов в формате -periods (и строк файла -schedule).
+func parsePeriodList(s string) ([]period, error) {
+	var out []period
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, rest, ok := strings.Cut(item, "=")
+		parts := strings.Split(rest, "/")
+		if !ok || name == "" || len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("periods %q: ожидается ИМЯ=ЧЧ:ММ/порог[/day|evening|night]", item)
+		}
+		p := period{name: strings.ToUpper(strings.TrimSpace(name))}
+		start, err := parseHHMM(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, fmt.Errorf("periods %q: %w", item, err)
+		}
+		p.start = start
+		if p.limit, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err != nil || p.limit <= 0 {
+			return nil, fmt.Errorf("periods %q: порог должен быть числом > 0", item)
+		}
+		p.kind = kindDay
+		switch {
+		case strings.Contains(p.name, "EVENING"):
+			p.kind = kindEvening
+		case strings.Contains(p.name, "NIGHT"):
+			p.kind = kindNight
+		}
+		if len(parts) == 3 {
+			k, err := parsePeriodKind(strings.TrimSpace(parts[2]))
+			if err != nil {
+				return nil, fmt.Errorf("periods %q: %w", item, err)
+			}
+			p.kind = k
+		}
+		out = append(out, p)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("periods: не задано ни одного периода")
+	}
+	return sortPeriods(out)
+}
+
+func parsePeriodKind(s string) (periodKind, error) {
+	for i, n := range kindNames {
+		if strings.EqualFold(s, n) {
+			return periodKind(i), nil
+		}
+	}
+	return 0, fmt.Errorf("неизвестный вид периода %q (day|evening|night)", s)
+}
+
+func sortPeriods(ps []period) ([]period, error) {
+	sort.Slice(ps, func(i, j int) bool { return ps[i].start < ps[j].start })
+	for i := 1; i < len(ps); i++ {
+		if ps[i].start == ps[i-1].start {
+			return nil, fmt.Errorf("periods: %s и %s начинаются одновременно", ps[i-1].name, ps[i].name)
+		}
+	}
+	return ps, nil
+}
+
+// periodAt — период, действующий в момент now: до первого начала в профиле дня
+// продолжается последний период предыдущего дня (через полночь).
+func (a *App) periodAt(now time.Time) *period {
+	m := now.Hour()*60 + now.Minute()
+	ps := a.profileFor(now)
+	if m < ps[0].start {
+		prev := a.profileFor(now.AddDate(0, 0, -1))
+		return &prev[len(prev)-1]
+	}
+	p := &ps[0]
+	for i := range ps {
+		if ps[i].start > m {
+			break
+		}
+		p = &ps[i]
+	}
+	return p
+}
+
+// kindMinutes — номинальная длительность каждого вида периода в сутках day (минуты):
+// до первого начала — последний период вчерашнего профиля.
+func (a *App) kindMinutes(day time.Time) [3]float64 {
+	var out [3]float64
+	ps := a.profileFor(day)
+	prev := a.profileFor(day.AddDate(0, 0, -1))
+	out[prev[len(prev)-1].kind] += float64(ps[0].start)
+	for i, p := range ps {
+		next := 24 * 60
+		if i+1 < len(ps) {
+			next = ps[i+1].start
+		}
+		out[p.kind] += float64(next - p.start)
+	}
+	return out
+}
+
+// В относительном режиме (-relative-delta) порог — фон плюс дельта; пока фона нет — порог периода.
+func (a *App) currentLimit(now time.Time) (string, float64) {
+	p := a.periodAt(now)
+	if a.cfg.RelativeDelta > 0 {
+		if f := a.floorAt(now); !math.IsNaN(f) {
+			return p.name, f + a.cfg.RelativeDelta
+		}
+	}
+	return p.name, p.limit
+}
+
+// periodsSummary — "DAY 07:00 55.0, NIGHT 23:00 45.0" для шапки.
+func (a *App) periodsSummary() string {
+	parts := make([]string, 0, len(a.periods))
+	for _, p := range a.periods {
+		parts = append(parts, fmt.Sprintf("%s %02d:%02d %.1f", p.name, p.start/60, p.start%60, p.limit))
+	}
+	if a.schedule != nil {
+		parts = append(parts, "расписание "+a.cfg.Schedule)
+	}
+	if a.cfg.RelativeDelta > 0 {
+		return fmt.Sprintf("фон+%.1f (пока фона нет: %s)", a.cfg.RelativeDelta, strings.Join(parts, ", "))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// modeWidth — ширина колонки Mode в live-выводе по самому длинному имени периода.
+func (a *App) modeWidth() int {
+	w := 5
+	for _, p := range a.periods {
+		w = max(w, len(p.name))
+	}
+	if a.schedule != nil {
+		for _, ps := range a.schedule.profiles() {
+			for _, p := range ps {
+				w = max(w, len(p.name))
+			}
+		}
+	}
+	return w
+}
+
+// dailyLevels — энергия и измеренное время за сутки по видам периодов.
+// Сутки календарные (00:00–24:00), как и ротация CSV.
+type dailyLevels struct {
+	energy [3]float64 // Σ 10^(Leq/10)·dt
+	dur    [3]float64 // секунд
+}
+
+func (a *App) addDaily(now time.Time, leq, dt float64) {
+	k := a.periodAt(now).kind
+	a.daily.energy[k] += math.Pow(10, leq/10) * dt
+	a.daily.dur[k] += dt
+}
+
+// dailyRow — Lday/Levening/Lnight и Lden/Ldn. Lden/Ldn считаются по номинальной
+// длительности периодов и не определены, если какой-то из них не измерялся.
+func (a *App) dailyRow(date string) iofs.DailyRow {
+	var lv [3]float64
+	for k := range lv {
+		lv[k] = math.NaN()
+		if a.daily.dur[k] > 0 {
+			lv[k] = mathx.PowerToDB(a.daily.energy[k] / a.daily.dur[k])
+		}
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", date, a.loc)
+	if err != nil {
+		day = a.wall.Now().In(a.loc)
+	}
+	nominal := a.kindMinutes(day)
+	var levels, durations, den, dn []float64
+	complete := true
+	for k := range lv {
+		if nominal[k] == 0 {
+			continue
+		}
+		if math.IsNaN(lv[k]) {
+			complete = false
+			break
+		}
+		levels = append(levels, lv[k])
+		durations = append(durations, nominal[k])
+		den = append(den, denPenalty[k])
+		dn = append(dn, dnPenalty[k])
+	}
+	lden, ldn := math.NaN(), math.NaN()
+	if complete {
+		lden = mathx.PenalizedLevel(levels, durations, den)
+		ldn = mathx.PenalizedLevel(levels, durations, dn)
+	}
+
+	return iofs.DailyRow{
+		Date: date,
+		Day:  lv[kindDay], Evening: lv[kindEvening], Night: lv[kindNight],
+		Lden: lden, Ldn: ldn,
+		DayS: a.daily.dur[kindDay], EveningS: a.daily.dur[kindEvening], NightS: a.daily.dur[kindNight],
+	}
+}
+
+// addRow — уровни и время строки sound_daily, записанной раньше за ту же дату
+// (до перезапуска): энергия восстанавливается из уровня и измеренного времени.
+func (d *dailyLevels) addRow(r iofs.DailyRow) {
+	for k, p := range [3][2]float64{{r.Day, r.DayS}, {r.Evening, r.EveningS}, {r.Night, r.NightS}} {
+		if math.IsNaN(p[0]) || p[1] <= 0 {
+			continue
+		}
+		d.energy[k] += math.Pow(10, p[0]/10) * p[1]
+		d.dur[k] += p[1]
+	}
+}
+
+// writeDaily — суточные показатели в sound_daily за дату date; вызывается при ротации
+// и при завершении (неполные сутки — см. *_s). Файл за дату один: после перезапуска
+// строка прошлой сессии объединяется с новой и файл перезаписывается. Накопитель сбрасывается.
+func (a *App) writeDaily(date string) {
+	defer func() { a.daily = dailyLevels{} }()
+	if a.daily.dur == [3]float64{} {
+		return
+	}
+	// {created} — полночь даты: имя не зависит от момента записи
+	day, err := time.ParseInLocation("2006-01-02", date, a.loc)
+	if err != nil {
+		day = a.wall.Now().In(a.loc)
+	}
+	path := a.layout.CSVPath(date, "sound_daily", day)
+	prev, ok, err := iofs.ReadDailyRow(path, a.csvDelim, date)
+	if err != nil {
+		// испорченный файл не перезаписываем: прошлые данные за дату важнее
+		atomic.AddUint64(&a.stats.CSVErrors, 1)
+		fmt.Printf("%s[DAILY ERROR] %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
+		return
+	}
+	if ok {
+		a.daily.addRow(prev)
+	}
+	rec := a.dailyRow(date).Record()
+
+	if err := iofs.RewriteCSV(path, a.csvDelim, iofs.DailyCSVHeader(string(a.weighting.Weighting())), rec); err != nil {
+		atomic.AddUint64(&a.stats.CSVErrors, 1)
+		fmt.Printf("%s[DAILY ERROR] %s: %v%s\n", sysx.ClrRed, path, err, sysx.ClrReset)
+		return
+	}
+
+	fmt.Printf("%s📊 %s: L%sday %s | L%sevening %s | L%snight %s | Lden %s | Ldn %s → %s%s\n",
+		sysx.ClrCyan, date, a.weighting.Weighting(), orDash(rec[1]), a.weighting.Weighting(), orDash(rec[2]),
+		a.weighting.Weighting(), orDash(rec[3]), orDash(rec[4]), orDash(rec[5]), path, sysx.ClrReset)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
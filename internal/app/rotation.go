@@ -18,7 +18,9 @@ func (a *App) rotateIfDateChanged(now time.Time) {
 		return
 	}
 
-	// закрываем старые CSV
+	// дописываем строки прошлого дня (в т.ч. закрытые окна статистики) и закрываем старые CSV
+	a.csvPending.Wait()
+	a.writeDaily(a.currentDate)
 	if a.csvWriter != nil {
 		a.csvWriter.Flush()
 		_ = a.csvFile.Close()
@@ -27,26 +29,55 @@ func (a *App) rotateIfDateChanged(now time.Time) {
 		a.csvAllWriter.Flush()
 		_ = a.csvAllFile.Close()
 	}
+	if a.statsWriter != nil {
+		a.statsWriter.Flush()
+		_ = a.statsFile.Close()
+	}
+	if a.bandWriter != nil {
+		a.bandWriter.Flush()
+		_ = a.bandFile.Close()
+	}
 
-	root, csvDir, wavDir, err := iofs.EnsureOutDirForDate(newDate)
+	root, csvDir, err := a.layout.EnsureForDate(newDate, now)
 	if err != nil {
 		fmt.Printf("%s[ROTATE ERROR] %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
 		return
 	}
 
-	file, writer, path, err := iofs.CreateCSV(csvDir, "sound_log", a.csvDelim, iofs.DefaultCSVHeader)
+	file, writer, path, err := iofs.CreateCSV(a.layout, newDate, "sound_log", now, a.csvDelim, a.eventHeader)
 	if err != nil {
 		fmt.Printf("%s[ROTATE ERROR] CSV(events): %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
 		return
 	}
-	allFile, allWriter, allPath, err := iofs.CreateCSV(csvDir, "sound_all", a.csvDelim, iofs.DefaultCSVHeader)
+	allFile, allWriter, allPath, err := iofs.CreateCSV(a.layout, newDate, "sound_all", now, a.csvDelim, a.csvHeader)
 	if err != nil {
 		_ = file.Close()
 		fmt.Printf("%s[ROTATE ERROR] CSV(all): %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
 		return
 	}
 
-	a.outDirRoot, a.outDirCSV, a.outDirWAV = root, csvDir, wavDir
+	statsFile, statsWriter, statsPath, err := iofs.CreateCSV(a.layout, newDate, "sound_stats", now, a.csvDelim, a.statsHeader)
+	if err != nil {
+		_ = file.Close()
+		_ = allFile.Close()
+		fmt.Printf("%s[ROTATE ERROR] CSV(stats): %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
+		return
+	}
+
+	if a.bandWriter != nil {
+		bandFile, bandWriter, bandPath, err := iofs.CreateCSV(a.layout, newDate, "sound_bands", now, a.csvDelim, a.bandHeader)
+		if err != nil {
+			_ = file.Close()
+			_ = allFile.Close()
+			_ = statsFile.Close()
+			fmt.Printf("%s[ROTATE ERROR] CSV(bands): %v%s\n", sysx.ClrRed, err, sysx.ClrReset)
+			return
+		}
+		a.bandFile, a.bandWriter, a.bandPath = bandFile, bandWriter, bandPath
+	}
+
+	a.outDirRoot, a.outDirCSV = root, csvDir
+	a.statsFile, a.statsWriter, a.statsPath = statsFile, statsWriter, statsPath
 	a.csvFile, a.csvWriter, a.csvPath = file, writer, path
 	a.csvAllFile, a.csvAllWriter, a.csvAllPath = allFile, allWriter, allPath
 	a.currentDate = newDate
@@ -64,4 +95,13 @@ func (a *App) printStats() {
 	fmt.Printf("События CSV: %d | Полный CSV: %d\n", atomic.LoadUint64(&a.stats.CSVEventsWritten), atomic.LoadUint64(&a.stats.CSVAllWritten))
 	fmt.Printf("WAV файлов: %d | Ошибки WAV: %d\n", atomic.LoadUint64(&a.stats.WAVFilesSaved), atomic.LoadUint64(&a.stats.WAVErrors))
 	fmt.Printf("Ошибки CSV: %d | Проверок диска: %d\n", atomic.LoadUint64(&a.stats.CSVErrors), atomic.LoadUint64(&a.stats.DiskChecks))
+	fmt.Printf("Разрывы захвата: %d (%.1f с) | Потеряно WAV: %d\n", atomic.LoadUint64(&a.stats.CaptureGaps),
+		float64(atomic.LoadUint64(&a.stats.CaptureGapMs))/1000, atomic.LoadUint64(&a.stats.WAVDropped))
+	fmt.Printf("Очереди полны: CSV %d | кадры %d\n", atomic.LoadUint64(&a.stats.CSVQueueFull), atomic.LoadUint64(&a.stats.FrameQueueFull))
+	fmt.Printf("Буферов с перегрузкой (CLIPPED): %d\n", atomic.LoadUint64(&a.stats.ClippedBuffers))
+	fmt.Printf("Потери устройства: %d | Неудачных переоткрытий: %d\n", atomic.LoadUint64(&a.stats.DeviceLost), atomic.LoadUint64(&a.stats.DeviceOpenErrors))
+	if a.clock != nil {
+		fmt.Printf("Перепривязок часов: %d | Наибольший дрейф: %+.1f мс\n", atomic.LoadUint64(&a.stats.ClockResyncs),
+			float64(a.clock.maxDrift)/float64(time.Millisecond))
+	}
 }
@@ -0,0 +1,434 @@
+// C:\_Projects_Go\AcousticLog\internal\app\rules.go
+
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	iofs "acousticlog/internal/io"
+	"acousticlog/internal/mathx"
+)
+
+// Правила тревог (-rules): по строке "ИМЯ: условие [and условие …] [during период]",
+// например
+//
+//	PARTY: LAeq_5m > 50 during night
+//	BANGS: count(IMPULSE, 10m) >= 5
+//	PEAK:  LCpeak > 100
+//
+// Величины: L{A|C|Z}eq (буфер), L{A|C|Z}eq_{окно} (скользящее), L{A|C|Z}peak (пик
+// скорректированного сигнала), L{W}{F|S|I}[max] (только для -weighting W), LZpeak — как
+// в sound_log; count(ВИД, окно) — сколько раз детектор ВИД (EXCEEDED|IMPULSE|BAND|LOWFREQ)
+// срабатывал за окно. during — имя периода или вид day|evening|night.
+// Каждое правило — свой поток событий (events.go) со своим видом = ИМЯ.
+
+var (
+	ruleNameRe  = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+	ruleCondRe  = regexp.MustCompile(`^(\S+|count\([^)]*\))\s*(>=|<=|>|<)\s*(-?[0-9.]+)$`)
+	ruleCountRe = regexp.MustCompile(`^count\(\s*([A-Za-z]+)\s*,\s*(\S+)\s*\)$`)
+	ruleLeqRe   = regexp.MustCompile(`^L([ACZ])(eq|peak)(?:_(\S+))?$`)
+	ruleTimeRe  = regexp.MustCompile(`^L([ACZ])([FSI])(max)?$`)
+	ruleAndRe   = regexp.MustCompile(`(?i)\s+and\s+`)
+)
+
+type operandKind int
+
+const (
+	opLevel   operandKind = iota // поле levels буфера
+	opLeq                        // Leq буфера по коррекции w
+	opRolling                    // скользящий Leq за d
+	opPeak                       // пик по коррекции w
+	opCount                      // срабатывания детектора kind за d
+)
+
+type operand struct {
+	kind  operandKind
+	w     mathx.Weighting
+	d     time.Duration
+	field func(levels) float64 // opLevel
+	count string               // opCount
+}
+
+type ruleCond struct {
+	op    operand
+	cmp   string
+	value float64
+}
+
+type rule struct {
+	name   string
+	src    string // текст условия — в колонку Detector
+	conds  []ruleCond
+	during string // "" — всегда
+	track  eventTrack
+}
+
+// rollingLeq — энергия за последние d.
+type rollingLeq struct {
+	d     time.Duration
+	times []time.Time
+	e, t  []float64
+	sumE  float64
+	sumT  float64
+}
+
+func (r *rollingLeq) add(now time.Time, ms, dt float64) {
+	drop := 0
+	for drop < len(r.times) && now.Sub(r.times[drop]) >= r.d {
+		r.sumE -= r.e[drop]
+		r.sumT -= r.t[drop]
+		drop++
+	}
+	r.times = append(r.times[drop:], now)
+	r.e = append(r.e[drop:], ms*dt)
+	r.t = append(r.t[drop:], dt)
+	r.sumE += ms * dt
+	r.sumT += dt
+}
+
+// ms — средний квадрат за окно; NaN, пока окно не набрано.
+func (r *rollingLeq) ms() float64 {
+	if r.sumT < 0.95*r.d.Seconds() || r.sumT <= 0 {
+		return math.NaN()
+	}
+	return r.sumE / r.sumT
+}
+
+// onsetLog — моменты включения детектора (фронт false → true) за последние d.
+type onsetLog struct {
+	d     time.Duration
+	prev  bool
+	times []time.Time
+}
+
+func (o *onsetLog) add(now time.Time, on bool) {
+	if on && !o.prev {
+		o.times = append(o.times, now)
+	}
+	o.prev = on
+	drop := 0
+	for drop < len(o.times) && now.Sub(o.times[drop]) >= o.d {
+		drop++
+	}
+	o.times = o.times[drop:]
+}
+
+func (o *onsetLog) count(now time.Time, d time.Duration) int {
+	n := 0
+	for _, t := range o.times {
+		if now.Sub(t) < d {
+			n++
+		}
+	}
+	return n
+}
+
+type rollKey struct {
+	w mathx.Weighting
+	d time.Duration
+}
+
+// ruleSet — правила и общее для них состояние: доп. коррекции, скользящие окна, счётчики.
+type ruleSet struct {
+	list    []*rule
+	filters map[mathx.Weighting]*mathx.WeightingFilter
+	ms      map[mathx.Weighting]float64 // средний квадрат буфера по коррекции
+	peak    map[mathx.Weighting]float64 // пик буфера по коррекции (доли шкалы)
+	rolling map[rollKey]*rollingLeq
+	onsets  map[string]*onsetLog
+}
+
+// parseRules — файл правил; weighting — для величин L{W}{F|S|I}, periods — все периоды
+// (в т.ч. из -schedule) для during.
+func parseRules(path string, weighting mathx.Weighting, periods []period) (ruleSet, error) {
+	rs := ruleSet{
+		filters: map[mathx.Weighting]*mathx.WeightingFilter{},
+		ms:      map[mathx.Weighting]float64{},
+		peak:    map[mathx.Weighting]float64{},
+		rolling: map[rollKey]*rollingLeq{},
+		onsets:  map[string]*onsetLog{},
+	}
+	if path == "" {
+		return rs, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return rs, fmt.Errorf("rules: %w", err)
+	}
+	defer f.Close()
+
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		r, err := rs.parseRule(line, weighting, periods)
+		if err != nil {
+			return rs, fmt.Errorf("rules %s:%d: %w", path, n, err)
+		}
+		if seen[r.name] {
+			return rs, fmt.Errorf("rules %s:%d: правило %s уже есть", path, n, r.name)
+		}
+		seen[r.name] = true
+		r.track.rule = r
+		rs.list = append(rs.list, r)
+	}
+	return rs, sc.Err()
+}
+
+func (rs *ruleSet) parseRule(line string, weighting mathx.Weighting, periods []period) (*rule, error) {
+	name, body, ok := strings.Cut(line, ":")
+	if !ok {
+		return nil, fmt.Errorf("ожидается \"ИМЯ: условие\"")
+	}
+	r := &rule{name: strings.ToUpper(strings.TrimSpace(name)), src: strings.Join(strings.Fields(body), " ")}
+	if !ruleNameRe.MatchString(r.name) {
+		return nil, fmt.Errorf("имя правила %q: латиница, цифры и _", r.name)
+	}
+	if isReservedName(r.name) {
+		return nil, fmt.Errorf("имя правила %s занято встроенным статусом или видом события", r.name)
+	}
+
+	body = r.src
+	if i := strings.LastIndex(strings.ToLower(body), " during "); i >= 0 {
+		r.during = strings.TrimSpace(body[i+len(" during "):])
+		body = body[:i]
+		if !knownPeriod(r.during, periods) {
+			return nil, fmt.Errorf("during %q: нет такого периода (имя периода или day|evening|night)", r.during)
+		}
+	}
+	for _, part := range ruleAndRe.Split(body, -1) {
+		c, err := rs.parseCond(strings.TrimSpace(part), weighting)
+		if err != nil {
+			return nil, err
+		}
+		r.conds = append(r.conds, c)
+	}
+	return r, nil
+}
+
+func knownPeriod(s string, periods []period) bool {
+	for _, k := range kindNames {
+		if strings.EqualFold(s, k) {
+			return true
+		}
+	}
+	for _, p := range periods {
+		if strings.EqualFold(s, p.name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rs *ruleSet) parseCond(s string, weighting mathx.Weighting) (ruleCond, error) {
+	m := ruleCondRe.FindStringSubmatch(s)
+	if m == nil {
+		return ruleCond{}, fmt.Errorf("%q: ожидается «величина оператор число», оператор > >= < <=", s)
+	}
+	c := ruleCond{cmp: m[2]}
+	var err error
+	if c.value, err = strconv.ParseFloat(m[3], 64); err != nil {
+		return c, fmt.Errorf("%q: %w", s, err)
+	}
+	if c.op, err = rs.parseOperand(m[1], weighting); err != nil {
+		return c, fmt.Errorf("%q: %w", s, err)
+	}
+	return c, nil
+}
+
+func (rs *ruleSet) parseOperand(s string, weighting mathx.Weighting) (operand, error) {
+	if m := ruleCountRe.FindStringSubmatch(s); m != nil {
+		kind := strings.ToUpper(m[1])
+		if !isBuiltinKind(kind) {
+			return operand{}, fmt.Errorf("count: неизвестный вид %q", m[1])
+		}
+		d, err := time.ParseDuration(m[2])
+		if err != nil || d <= 0 {
+			return operand{}, fmt.Errorf("count: окно %q", m[2])
+		}
+		o := rs.onsets[kind]
+		if o == nil {
+			o = &onsetLog{}
+			rs.onsets[kind] = o
+		}
+		o.d = max(o.d, d)
+		return operand{kind: opCount, count: kind, d: d}, nil
+	}
+	if s == "LZpeak" {
+		return operand{kind: opLevel, field: func(lv levels) float64 { return lv.peak }}, nil
+	}
+	if m := ruleTimeRe.FindStringSubmatch(s); m != nil {
+		if mathx.Weighting(m[1]) != weighting {
+			return operand{}, fmt.Errorf("%s: временные характеристики есть только для -weighting %s", s, weighting)
+		}
+		fields := map[string]func(levels) float64{
+			"F": func(lv levels) float64 { return lv.lf }, "Fmax": func(lv levels) float64 { return lv.lfMax },
+			"S": func(lv levels) float64 { return lv.ls }, "Smax": func(lv levels) float64 { return lv.lsMax },
+			"I": func(lv levels) float64 { return lv.li }, "Imax": func(lv levels) float64 { return lv.liMax },
+		}
+		return operand{kind: opLevel, field: fields[m[2]+m[3]]}, nil
+	}
+	m := ruleLeqRe.FindStringSubmatch(s)
+	if m == nil {
+		return operand{}, fmt.Errorf("неизвестная величина %q", s)
+	}
+	w := mathx.Weighting(m[1])
+	rs.filters[w] = nil // создаётся в init, когда известна частота
+	switch {
+	case m[2] == "peak" && m[3] != "":
+		return operand{}, fmt.Errorf("%s: у пика нет окна", s)
+	case m[2] == "peak":
+		return operand{kind: opPeak, w: w}, nil
+	case m[3] == "":
+		return operand{kind: opLeq, w: w}, nil
+	}
+	d, err := time.ParseDuration(m[3])
+	if err != nil || d < time.Second {
+		return operand{}, fmt.Errorf("%s: окно должно быть длительностью ≥ 1s", s)
+	}
+	k := rollKey{w, d}
+	if rs.rolling[k] == nil {
+		rs.rolling[k] = &rollingLeq{d: d}
+	}
+	return operand{kind: opRolling, w: w, d: d}, nil
+}
+
+func (rs *ruleSet) has(name string) bool {
+	for _, r := range rs.list {
+		if r.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// resetWindows — после окна исключения: скользящие Leq набираются заново. Срабатывания
+// для count до окна настоящие и остаются, забывается только состояние детектора.
+func (rs *ruleSet) resetWindows() {
+	for _, r := range rs.rolling {
+		*r = rollingLeq{d: r.d}
+	}
+	for _, o := range rs.onsets {
+		o.prev = false
+	}
+}
+
+// init — фильтры коррекций для правил (частота дискретизации известна после Open).
+func (rs *ruleSet) init(sampleRate int) {
+	for w := range rs.filters {
+		rs.filters[w] = mathx.NewWeightingFilter(w, sampleRate)
+	}
+}
+
+// evalRules — учёт буфера и имена сработавших правил; detectors — состояние встроенных
+// детекторов в этом буфере (для count).
+func (a *App) evalRules(now time.Time, samples []int16, lv levels, dt float64, detectors map[string]bool) map[*rule]bool {
+	rs := &a.rules
+	if len(rs.list) == 0 {
+		return nil
+	}
+	for w, f := range rs.filters {
+		ys := f.ApplyInt16(samples)
+		var sum, pk float64
+		for _, y := range ys {
+			sum += y * y
+			pk = math.Max(pk, math.Abs(y))
+		}
+		rs.ms[w] = sum / float64(len(ys))
+		rs.peak[w] = pk
+	}
+	for k, r := range rs.rolling {
+		r.add(now, rs.ms[k.w], dt)
+	}
+	for kind, o := range rs.onsets {
+		o.add(now, detectors[kind])
+	}
+
+	p := a.periodAt(now)
+	spl := func(ms float64) float64 {
+		if math.IsNaN(ms) || ms <= 0 {
+			return math.NaN()
+		}
+		return math.Max(0, mathx.PowerToDB(ms)+a.splOffset)
+	}
+	out := map[*rule]bool{}
+	for _, r := range rs.list {
+		if r.during != "" && !strings.EqualFold(r.during, p.name) && !strings.EqualFold(r.during, kindNames[p.kind]) {
+			continue
+		}
+		ok := true
+		for _, c := range r.conds {
+			var v float64
+			switch c.op.kind {
+			case opLevel:
+				v = c.op.field(lv)
+			case opLeq:
+				v = spl(rs.ms[c.op.w])
+			case opRolling:
+				v = spl(rs.rolling[rollKey{c.op.w, c.op.d}].ms())
+			case opPeak:
+				v = spl(rs.peak[c.op.w] * rs.peak[c.op.w])
+			case opCount:
+				v = float64(rs.onsets[c.op.count].count(now, c.op.d))
+			}
+			ok = ok && compare(v, c.cmp, c.value)
+		}
+		if ok {
+			out[r] = true
+		}
+	}
+	return out
+}
+
+// isReservedName — имя, которое уже пишется в колонки Status/Kind/Quality: правило с ним
+// было бы не отличить от встроенного детектора или строки SYSTEM.
+func isReservedName(name string) bool {
+	if isBuiltinKind(name) {
+		return true
+	}
+	switch name {
+	case "OK", "NEAR", "SYSTEM", statusExcluded, statusSilent, "WAV_DROPPED",
+		iofs.QualityClipped, iofs.QualityGap, iofs.QualityDeviceError, iofs.QualityShutdown:
+		return true
+	}
+	// GAP_Nms, CLOCK_DRIFT_…, DEVICE_LOST/DEVICE_RESTORED, FATAL_DISK_SPACE_…
+	for _, p := range []string{"GAP_", "CLOCK_DRIFT_", "DEVICE_", "FATAL_"} {
+		if strings.HasPrefix(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func isBuiltinKind(k string) bool {
+	switch k {
+	case iofs.EventKindExceeded, iofs.EventKindImpulse, iofs.EventKindBand, iofs.EventKindLowFreq:
+		return true
+	}
+	return false
+}
+
+// compare — NaN (величина ещё не определена) — всегда false.
+func compare(v float64, cmp string, x float64) bool {
+	switch cmp {
+	case ">":
+		return v > x
+	case ">=":
+		return v >= x
+	case "<":
+		return v < x
+	case "<=":
+		return v <= x
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+// C:\_Projects_Go\AcousticLog\internal\app\rules_test.go
+
+package app
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	iofs "acousticlog/internal/io"
+	"acousticlog/internal/mathx"
+)
+
+// Имя правила не должно совпадать со статусом, видом события или качеством,
+// которые программа пишет сама.
+func TestParseRuleReservedNames(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		ok   bool
+	}{
+		{"PARTY", true},
+		{"GAPS", true},
+		{"EXCEEDED", false},
+		{"impulse", false},
+		{"LOWFREQ", false},
+		{"OK", false},
+		{"NEAR", false},
+		{"SYSTEM", false},
+		{"EXCLUDED", false},
+		{"SILENT", false},
+		{"CLIPPED", false},
+		{"GAP", false},
+		{"GAP_500MS", false},
+		{"DEVICE_LOST", false},
+		{"DEVICE_ERROR", false},
+		{"SHUTDOWN", false},
+		{"WAV_DROPPED", false},
+		{"CLOCK_DRIFT_X", false},
+	} {
+		rs := ruleSet{filters: map[mathx.Weighting]*mathx.WeightingFilter{}, rolling: map[rollKey]*rollingLeq{}, onsets: map[string]*onsetLog{}}
+		_, err := rs.parseRule(tc.name+": LAeq > 50", mathx.WeightingA, nil)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: ошибка %v, ожидалось допустимо=%v", tc.name, err, tc.ok)
+		}
+	}
+}
+
+// writeRules — файл правил во временном каталоге.
+func writeRules(t *testing.T, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "rules.txt")
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// describeRule — разобранное правило одной строкой: "ИМЯ: операнд оп число, … [during …]".
+func describeRule(r *rule) string {
+	var conds []string
+	for _, c := range r.conds {
+		var op string
+		switch c.op.kind {
+		case opLevel:
+			op = "level"
+		case opLeq:
+			op = "L" + string(c.op.w) + "eq"
+		case opRolling:
+			op = fmt.Sprintf("L%seq[%s]", c.op.w, c.op.d)
+		case opPeak:
+			op = "L" + string(c.op.w) + "peak"
+		case opCount:
+			op = fmt.Sprintf("count[%s,%s]", c.op.count, c.op.d)
+		}
+		conds = append(conds, fmt.Sprintf("%s %s %g", op, c.cmp, c.value))
+	}
+	s := r.name + ": " + strings.Join(conds, ", ")
+	if r.during != "" {
+		s += " during " + r.during
+	}
+	return s
+}
+
+func TestParseRules(t *testing.T) {
+	periods, err := parsePeriodList("DAY=07:00/55,QUIET=22:00/50/evening,NIGHT=23:00/45")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		line string
+		want string // "" — ожидается ошибка
+	}{
+		{"PARTY: LAeq_5m > 50 during night", "PARTY: LAeq[5m0s] > 50 during night"},
+		{"BANGS: count(IMPULSE, 10m) >= 5", "BANGS: count[IMPULSE,10m0s] >= 5"},
+		{"PEAK:  LCpeak > 100", "PEAK: LCpeak > 100"},
+		{"bass: LCeq >= 70 and LAeq < 50 during QUIET", "BASS: LCeq >= 70, LAeq < 50 during QUIET"},
+		{"LOUD: LAFmax > 80 AND LZpeak <= 120", "LOUD: level > 80, level <= 120"},
+		{"X: LAeq_1s < -3", "X: LAeq[1s] < -3"},
+		{"NO_COLON LAeq > 50", ""},
+		{"X: LAeq = 50", ""},
+		{"X: LQeq > 50", ""},
+		{"X: LCpeak_1m > 100", ""},
+		{"X: LAeq_500ms > 50", ""},
+		{"X: LAeq_abc > 50", ""},
+		{"X: LCF > 50", ""}, // временные характеристики — только для -weighting A
+		{"X: count(CLICK, 1m) > 1", ""},
+		{"X: count(IMPULSE, 0s) > 1", ""},
+		{"X: LAeq > 50 during weekend", ""},
+		{"1X: LAeq > 50", ""},
+	} {
+		t.Run(tc.line, func(t *testing.T) {
+			rs, err := parseRules(writeRules(t, "# комментарий\n\n"+tc.line+"\n"), mathx.WeightingA, periods)
+			if tc.want == "" {
+				if err == nil {
+					t.Fatalf("ожидалась ошибка, разобрано %q", describeRule(rs.list[0]))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rs.list) != 1 {
+				t.Fatalf("правил %d, ожидалось 1", len(rs.list))
+			}
+			if got := describeRule(rs.list[0]); got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+			if rs.list[0].track.rule != rs.list[0] {
+				t.Error("поток событий правила не привязан к правилу")
+			}
+		})
+	}
+
+	if _, err := parseRules(writeRules(t, "A: LAeq > 50\na: LCeq > 60\n"), mathx.WeightingA, periods); err == nil {
+		t.Error("повтор имени правила: ожидалась ошибка")
+	}
+}
+
+// ruleBuf — буфер теста evalRules: 250 мс при 8 кГц (шаг шкалы и сумма длительностей
+// окна точны в float64, границы заполнения окна не плавают).
+const (
+	ruleRate = 8000
+	ruleN    = 2000
+)
+
+// ruleTone — синус 1 кГц с амплитудой amp (доли шкалы) для буфера i: фаза непрерывна
+// между буферами, пик синуса попадает точно в сэмпл.
+func ruleTone(i int, amp float64) []int16 {
+	out := make([]int16, ruleN)
+	for k := range out {
+		n := i*ruleN + k
+		out[k] = int16(math.Round(amp * 32767 * math.Sin(2*math.Pi*1000*float64(n)/ruleRate)))
+	}
+	return out
+}
+
+// ruleAmp — амплитуда синуса, у которого при -spl-offset 110 Leq равен db.
+func ruleAmp(db float64) float64 {
+	return math.Sqrt2 * math.Pow(10, (db-110)/20)
+}
+
+// Правила из примеров -rules на потоке буферов: скользящий Leq не срабатывает до
+// заполнения окна, during ограничивает периодом, count считает фронты детектора в окне,
+// and требует всех условий.
+func TestEvalRules(t *testing.T) {
+	periods, err := parsePeriodList("DAY=07:00/55,NIGHT=23:00/45")
+	if err != nil {
+		t.Fatal(err)
+	}
+	type buf struct {
+		db       float64 // Leq тона
+		detector string  // включённый встроенный детектор
+	}
+	const minute = 240 // буферов в минуте
+	every := func(step, hold int, db float64) func(int) buf {
+		return func(i int) buf {
+			if i%step < hold {
+				return buf{db, iofs.EventKindImpulse}
+			}
+			return buf{db: db}
+		}
+	}
+	for _, tc := range []struct {
+		name  string
+		rule  string
+		start string // ЧЧ:ММ
+		n     int    // буферов
+		buf   func(i int) buf
+		want  string // срабатывания сериями: "0×N 1×M"
+	}{
+		{"rolling: window fills first", "PARTY: LAeq_5m > 50 during night", "23:00", 6 * minute,
+			func(int) buf { return buf{db: 60} }, "0×1139 1×301"},
+		{"rolling: during night only", "PARTY: LAeq_5m > 50 during night", "22:50", 15 * minute,
+			func(int) buf { return buf{db: 60} }, "0×2400 1×1200"},
+		{"rolling: below threshold", "PARTY: LAeq_5m > 50 during night", "23:00", 6 * minute,
+			func(int) buf { return buf{db: 40} }, "0×1440"},
+		{"count: fifth impulse within 10m", "BANGS: count(IMPULSE, 10m) >= 5", "12:00", 12 * minute,
+			every(minute, 1, 40), "0×960 1×1920"},
+		{"count: held detector is one onset", "BANGS: count(IMPULSE, 10m) >= 5", "12:00", 12 * minute,
+			every(minute, 3, 40), "0×960 1×1920"},
+		{"count: old impulses leave the window", "BANGS: count(IMPULSE, 10m) >= 5", "12:00", 12 * minute,
+			every(5*minute/2, 1, 40), "0×2880"},
+		{"peak", "PEAK: LCpeak > 100", "12:00", 8,
+			func(i int) buf { return buf{db: []float64{85, 85, 98, 98, 104, 104, 85, 85}[i]} }, "0×2 1×4 0×2"},
+		{"peak and Leq", "MID: LCpeak > 100 and LAeq < 100", "12:00", 8,
+			func(i int) buf { return buf{db: []float64{85, 85, 98, 98, 104, 104, 85, 85}[i]} }, "0×2 1×2 0×4"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rs, err := parseRules(writeRules(t, tc.rule), mathx.WeightingA, periods)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rs.init(ruleRate)
+			a := &App{loc: time.UTC, periods: periods, splOffset: 110, rules: rs}
+			start, _ := time.Parse("2006-01-02 15:04", "2025-10-20 "+tc.start)
+
+			var got []string
+			prev, run := false, 0
+			for i := 0; i < tc.n; i++ {
+				b := tc.buf(i)
+				now := start.Add(time.Duration(i) * ruleN * time.Second / ruleRate)
+				alarms := a.evalRules(now, ruleTone(i, ruleAmp(b.db)), levels{}, float64(ruleN)/ruleRate,
+					map[string]bool{b.detector: true})
+				on := alarms[a.rules.list[0]]
+				if i > 0 && on != prev {
+					got = append(got, fmt.Sprintf("%d×%d", boolInt(prev), run))
+					run = 0
+				}
+				prev = on
+				run++
+			}
+			got = append(got, fmt.Sprintf("%d×%d", boolInt(prev), run))
+			if s := strings.Join(got, " "); s != tc.want {
+				t.Errorf("got %s, want %s", s, tc.want)
+			}
+		})
+	}
+}
+
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,274 @@
+// C:\_Projects_Go\AcousticLog\internal\app\run_test.go
+
+package app
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock — часы теста: After не ждёт, а сразу переводит время вперёд.
+type fakeClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = c.t.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.t
+	return ch
+}
+
+// runSynth — полный прогон Run на сценарии генератора в каталог dir.
+func runSynth(t *testing.T, dir string, clk Clock, args ...string) {
+	t.Helper()
+	cfg, err := parseArgs(append([]string{"-source", "synth", "-out-root", dir, "-tz", "UTC"}, args...), true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Clock = clk
+	if err := Run(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readCSV — строки журнала prefix (без заголовка) из всех дат прогона.
+func readCSV(t *testing.T, dir, prefix string) [][]string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "CSV", prefix+"_*.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(paths)
+	var rows [][]string
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff")))
+		r.Comma = ';'
+		recs, err := r.ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		rows = append(rows, recs[1:]...)
+	}
+	return rows
+}
+
+// statusRuns — статусы sound_all сжатыми сериями: "OK×15 EXCEEDED×10".
+func statusRuns(rows [][]string) string {
+	var out []string
+	for i := 0; i < len(rows); {
+		j := i
+		for j < len(rows) && rows[j][11] == rows[i][11] {
+			j++
+		}
+		out = append(out, fmt.Sprintf("%s×%d", rows[i][11], j-i))
+		i = j
+	}
+	return strings.Join(out, " ")
+}
+
+// wavFiles — WAV под dir: путь относительно dir (через "/") и длительность, с.
+func wavFiles(t *testing.T, dir string, rate int) []string {
+	t.Helper()
+	var out []string
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || fi.IsDir() || filepath.Ext(p) != ".wav" {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, p)
+		out = append(out, fmt.Sprintf("%s %.1fs", filepath.ToSlash(rel), float64(fi.Size()-44)/float64(2*rate)))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// eventRows — sound_log в виде "начало–конец вид клип качество" (время — без даты,
+// клип — относительно dir).
+func eventRows(t *testing.T, dir string) []string {
+	t.Helper()
+	var out []string
+	for _, r := range readCSV(t, dir, "sound_log") {
+		wav := r[12]
+		if rel, err := filepath.Rel(dir, wav); err == nil && !strings.HasPrefix(rel, "..") {
+			wav = filepath.ToSlash(rel)
+		}
+		out = append(out, fmt.Sprintf("%s–%s %s %s %s", r[0][11:], r[1][11:], r[3], wav, r[13]))
+	}
+	return out
+}
+
+// Сквозные прогоны: генератор -> Run -> sound_log, sound_all, клипы и часовая склейка.
+// Время сессии и темп -replay-realtime идут от подменённых часов. Клип — это pre-roll 2s
+// (не раньше начала часа) + событие + post-roll 1s.
+func TestRunSynth(t *testing.T) {
+	start := time.Date(2025, 10, 20, 11, 59, 50, 0, time.UTC)
+	exceed := "pink 3s level=40; tone 2s level=80; pink 3s level=40"
+	for _, tc := range []struct {
+		name     string
+		start    time.Time
+		args     []string
+		rules    string // текст файла -rules
+		events   []string
+		detector string // колонка Detector всех строк sound_log; "" — не проверяется
+		status   string
+		wavs     []string
+		elapsed  time.Duration // насколько ушли часы за прогон
+	}{
+		{name: "exceedance", start: start,
+			args:   []string{"-synth", exceed},
+			events: []string{"11:59:53.000–11:59:55.800 EXCEEDED 2025-10-20/WAV/11/EXCEEDED/noise_20251020_115951.000.wav OK"},
+			status: "OK×15 EXCEEDED×14 NEAR×1 OK×10",
+			wavs: []string{
+				"2025-10-20/WAV/11/EXCEEDED/noise_20251020_115951.000.wav 5.8s",
+				"2025-10-20/WAV/_Merged_Exceeded/merged_exceeded_2025-10-20_11.wav 5.8s",
+			}},
+		{name: "exceedance realtime", start: start,
+			args:   []string{"-replay-realtime", "-synth", exceed},
+			events: []string{"11:59:53.000–11:59:55.800 EXCEEDED 2025-10-20/WAV/11/EXCEEDED/noise_20251020_115951.000.wav OK"},
+			status: "OK×15 EXCEEDED×14 NEAR×1 OK×10",
+			wavs: []string{
+				"2025-10-20/WAV/11/EXCEEDED/noise_20251020_115951.000.wav 5.8s",
+				"2025-10-20/WAV/_Merged_Exceeded/merged_exceeded_2025-10-20_11.wav 5.8s",
+			},
+			elapsed: 8 * time.Second},
+		{name: "impulse", start: start,
+			args: []string{"-day-limit", "90", "-merge-kinds", "IMPULSE",
+				"-synth", "pink 6s level=40; impulse 1s level=100 at=500ms bg=40; pink 3s level=40"},
+			events: []string{"11:59:56.400–11:59:56.600 IMPULSE 2025-10-20/WAV/11/IMPULSE/noise_20251020_115954.400.wav OK"},
+			status: "OK×32 IMPULSE×1 OK×17",
+			wavs: []string{
+				"2025-10-20/WAV/11/IMPULSE/noise_20251020_115954.400.wav 3.2s",
+				"2025-10-20/WAV/_Merged_Exceeded/merged_exceeded_2025-10-20_11.wav 3.2s",
+			}},
+		{name: "hour boundary", start: start.Add(8 * time.Second),
+			args: []string{"-synth", "tone 4s level=80; pink 3s level=40"},
+			events: []string{
+				"11:59:58.000–12:00:00.000 EXCEEDED 2025-10-20/WAV/11/EXCEEDED/noise_20251020_115958.000.wav OK",
+				"12:00:00.000–12:00:02.800 EXCEEDED 2025-10-20/WAV/12/EXCEEDED/noise_20251020_120000.000.wav OK",
+			},
+			status: "EXCEEDED×24 NEAR×1 OK×10",
+			wavs: []string{
+				"2025-10-20/WAV/11/EXCEEDED/noise_20251020_115958.000.wav 2.0s",
+				"2025-10-20/WAV/12/EXCEEDED/noise_20251020_120000.000.wav 3.8s",
+				"2025-10-20/WAV/_Merged_Exceeded/merged_exceeded_2025-10-20_11.wav 2.0s",
+				"2025-10-20/WAV/_Merged_Exceeded/merged_exceeded_2025-10-20_12.wav 3.8s",
+			}},
+		{name: "silence inside event", start: start,
+			args:   []string{"-synth", "pink 2s level=40; tone 1s level=80; silence 1s; tone 1s level=80; pink 3s level=40"},
+			events: []string{"11:59:52.000–11:59:55.800 EXCEEDED 2025-10-20/WAV/11/EXCEEDED/noise_20251020_115950.000.wav OK"},
+			status: "OK×10 EXCEEDED×5 SILENT×5 EXCEEDED×9 NEAR×1 OK×10",
+			wavs: []string{
+				"2025-10-20/WAV/11/EXCEEDED/noise_20251020_115950.000.wav 6.8s",
+				"2025-10-20/WAV/_Merged_Exceeded/merged_exceeded_2025-10-20_11.wav 6.8s",
+			}},
+		{name: "exclusion", start: start.Add(6 * time.Second),
+			args:   []string{"-exclude", "* 11:59-12:00", "-synth", "tone 2s level=80; pink 3s level=40; tone 1s level=80; pink 3s level=40"},
+			events: []string{"12:00:01.000–12:00:02.800 EXCEEDED 2025-10-20/WAV/12/EXCEEDED/noise_20251020_120000.000.wav OK"},
+			status: "EXCLUDED×14 NEAR×1 OK×10 EXCEEDED×9 NEAR×1 OK×10",
+			wavs: []string{
+				"2025-10-20/WAV/12/EXCEEDED/noise_20251020_120000.000.wav 3.8s",
+				"2025-10-20/WAV/_Merged_Exceeded/merged_exceeded_2025-10-20_12.wav 3.8s",
+			}},
+		{name: "rules", start: start,
+			args:     []string{"-day-limit", "90", "-impulse-delta", "60", "-merge-kinds", "LOUD", "-synth", "pink 3s level=40; tone 3s level=70; pink 3s level=40"},
+			rules:    "LOUD: LAeq_1s > 60\nQUIET: LAeq_1s < 30\n",
+			events:   []string{"11:59:53.000–11:59:56.800 LOUD 2025-10-20/WAV/11/LOUD/noise_20251020_115951.000.wav OK"},
+			detector: "LAeq_1s > 60",
+			status:   "OK×15 LOUD×19 OK×11",
+			wavs: []string{
+				"2025-10-20/WAV/11/LOUD/noise_20251020_115951.000.wav 6.8s",
+				"2025-10-20/WAV/_Merged_Exceeded/merged_exceeded_2025-10-20_11.wav 6.8s",
+			}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			clk := &fakeClock{t: tc.start}
+			args := tc.args
+			if tc.rules != "" {
+				args = append([]string{"-rules", writeRules(t, tc.rules)}, args...)
+			}
+			runSynth(t, dir, clk, args...)
+
+			if got := eventRows(t, dir); !slices.Equal(got, tc.events) {
+				t.Errorf("sound_log:\n got %q\nwant %q", got, tc.events)
+			}
+			if tc.detector != "" {
+				for _, r := range readCSV(t, dir, "sound_log") {
+					if r[11] != tc.detector {
+						t.Errorf("sound_log %s: Detector %q, want %q", r[0], r[11], tc.detector)
+					}
+				}
+			}
+			// имена журналов — по шкале сессии, а не по часам системы
+			want := filepath.Join(dir, tc.start.Format("2006-01-02"), "CSV", "sound_all_"+tc.start.Format("20060102_150405")+".csv")
+			if _, err := os.Stat(want); err != nil {
+				t.Error(err)
+			}
+			if got := statusRuns(readCSV(t, dir, "sound_all")); got != tc.status {
+				t.Errorf("sound_all:\n got %s\nwant %s", got, tc.status)
+			}
+			if got := wavFiles(t, dir, 16000); !slices.Equal(got, tc.wavs) {
+				t.Errorf("WAV:\n got %q\nwant %q", got, tc.wavs)
+			}
+			if got := clk.Now().Sub(tc.start); got != tc.elapsed {
+				t.Errorf("часы ушли на %v, ожидалось %v", got, tc.elapsed)
+			}
+		})
+	}
+}
+
+// Перезапуск в те же сутки: sound_daily за дату остаётся одним файлом с одной строкой,
+// в которой объединены обе сессии.
+func TestRunSynthDailyRestart(t *testing.T) {
+	dir := t.TempDir()
+	runSynth(t, dir, &fakeClock{t: time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)}, "-synth", "tone 4s level=60")
+	runSynth(t, dir, &fakeClock{t: time.Date(2025, 10, 20, 13, 0, 0, 0, time.UTC)}, "-synth", "tone 6s level=70")
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "*", "CSV", "sound_daily_*.csv"))
+	if len(paths) != 1 {
+		t.Fatalf("файлов sound_daily %d, ожидался 1: %q", len(paths), paths)
+	}
+	rows := readCSV(t, dir, "sound_daily")
+	if len(rows) != 1 {
+		t.Fatalf("строк sound_daily %d, ожидалась 1: %q", len(rows), rows)
+	}
+	// 4 с при 60 дБ и 6 с при 70 дБ: 10·lg((4·10^6 + 6·10^7) / 10) = 68.06
+	if got := rows[0][0] + " " + rows[0][1] + " " + rows[0][6]; got != "2025-10-20 68.06 10.0" {
+		t.Errorf("got %q, want %q", got, "2025-10-20 68.06 10.0")
+	}
+}
+
+// Генератор не учит общий фон noise_floor.csv: 13 минут часа 11 покрыты
+// измерениями, но файл не появляется.
+func TestRunSynthKeepsNoiseFloor(t *testing.T) {
+	dir := t.TempDir()
+	runSynth(t, dir, &fakeClock{t: time.Date(2025, 10, 20, 11, 47, 0, 0, time.UTC)}, "-synth", "pink 14m level=40")
+	if _, err := os.Stat(filepath.Join(dir, "noise_floor.csv")); !os.IsNotExist(err) {
+		t.Errorf("noise_floor.csv записан (%v)", err)
+	}
+}
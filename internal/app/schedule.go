@@ -0,0 +1,151 @@
+// C:\_Projects_Go\AcousticLog\internal\app\schedule.go
+
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// schedule — периоды по дням недели (-schedule) и праздники (-holidays).
+// Дни без строки в файле живут по -periods; праздники — по профилю holiday,
+// а если его нет — как воскресенье.
+type schedule struct {
+	def      []period
+	week     [7][]period // по time.Weekday; nil — def
+	holiday  []period
+	holidays map[string]bool // "2006-01-02"
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// weekdayRange — "mon" или диапазон "mon-fri" (через конец недели: "fri-mon").
+func weekdayRange(d string) ([]time.Weekday, bool) {
+	from, to, isRange := strings.Cut(strings.ToLower(d), "-")
+	if !isRange {
+		to = from
+	}
+	wf, ok1 := weekdayNames[strings.TrimSpace(from)]
+	wt, ok2 := weekdayNames[strings.TrimSpace(to)]
+	if !ok1 || !ok2 {
+		return nil, false
+	}
+	var out []time.Weekday
+	for w := wf; ; w = (w + 1) % 7 {
+		out = append(out, w)
+		if w == wt {
+			return out, true
+		}
+	}
+}
+
+// parseSchedule — строки "дни: периоды", например
+//
+//	mon-fri: DAY=07:00/55, QUIET=13:00/45/night, DAY=15:00/55, NIGHT=22:00/45
+//	sat,sun,holiday: DAY=09:00/55, NIGHT=22:00/45
+//
+// Дни — mon…sun, диапазоны через "-" (fri-mon — через выходные), holiday — праздники.
+func parseSchedule(path string, def []period) (*schedule, error) {
+	s := &schedule{def: def, holidays: map[string]bool{}}
+	if path == "" {
+		return s, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("schedule: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		days, list, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("schedule %s:%d: ожидается \"дни: периоды\"", path, n)
+		}
+		ps, err := parsePeriodList(list)
+		if err != nil {
+			return nil, fmt.Errorf("schedule %s:%d: %w", path, n, err)
+		}
+		for _, d := range strings.Split(days, ",") {
+			d = strings.ToLower(strings.TrimSpace(d))
+			if d == "holiday" {
+				s.holiday = ps
+				continue
+			}
+			days, ok := weekdayRange(d)
+			if !ok {
+				return nil, fmt.Errorf("schedule %s:%d: неизвестный день %q (mon…sun, holiday)", path, n, d)
+			}
+			for _, w := range days {
+				s.week[w] = ps
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("schedule: %w", err)
+	}
+	return s, nil
+}
+
+// parseHolidays — по дате YYYY-MM-DD в строке (после # — комментарий).
+func (s *schedule) parseHolidays(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("holidays: %w", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", line); err != nil {
+			return fmt.Errorf("holidays %s:%d: ожидается дата YYYY-MM-DD, получено %q", path, n, line)
+		}
+		s.holidays[line] = true
+	}
+	return sc.Err()
+}
+
+// day — профиль дня недели w.
+func (s *schedule) day(w time.Weekday) []period {
+	if s.week[w] != nil {
+		return s.week[w]
+	}
+	return s.def
+}
+
+// profiles — все профили расписания (для ширины колонки Mode).
+func (s *schedule) profiles() [][]period {
+	return append([][]period{s.def, s.holiday}, s.week[:]...)
+}
+
+// profileFor — периоды, которые начинаются в календарный день t.
+func (a *App) profileFor(t time.Time) []period {
+	s := a.schedule
+	if s == nil {
+		return a.periods
+	}
+	if s.holidays[t.Format("2006-01-02")] {
+		if s.holiday != nil {
+			return s.holiday
+		}
+		return s.day(time.Sunday)
+	}
+	return s.day(t.Weekday())
+}
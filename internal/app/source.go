@@ -0,0 +1,126 @@
+// C:\_Projects_Go\AcousticLog\internal\app\source.go
+
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"acousticlog/internal/audio"
+	"acousticlog/internal/audio/pipe"
+	"acousticlog/internal/audio/synth"
+	"acousticlog/internal/audio/wavfile"
+	awin "acousticlog/internal/audio/winmm"
+)
+
+// newSource — выбор реализации audio.Source по флагу -source.
+func newSource(cfg *Config, loc *time.Location, clk Clock) (audio.Source, error) {
+	switch strings.ToLower(cfg.Source) {
+	case "", "winmm":
+		return awin.NewSource(cfg.SampleRate, cfg.BufferMs), nil
+	case "wav":
+		start, err := replayStart(cfg, loc, clk)
+		if err != nil {
+			return nil, err
+		}
+		return wavfile.NewSource(cfg.ReplayIn, start, cfg.BufferMs, cfg.ReplayRealtime), nil
+	case "synth":
+		start, err := replayStart(cfg, loc, clk)
+		if err != nil {
+			return nil, err
+		}
+		sc, err := synth.ParseScenario(cfg.SynthScenario, cfg.SPLOffset)
+		if err != nil {
+			return nil, err
+		}
+		return synth.NewSource(sc, synth.Options{
+			SampleRate: cfg.SampleRate,
+			BufferMs:   cfg.BufferMs,
+			Start:      start,
+			Realtime:   cfg.ReplayRealtime,
+			Seed:       cfg.SynthSeed,
+			Clock:      clk,
+		}), nil
+	case "pipe":
+		return pipe.NewSource(pipe.Config{
+			Command:    cfg.PipeCmd,
+			SampleRate: cfg.SampleRate,
+			Channels:   cfg.PipeChannels,
+			Format:     cfg.PipeFormat,
+			BufferMs:   cfg.BufferMs,
+		}), nil
+	default:
+		return nil, fmt.Errorf("неизвестный источник звука %q", cfg.Source)
+	}
+}
+
+// replayStart — время первого кадра офлайн-источника (-replay-start), по умолчанию — сейчас.
+func replayStart(cfg *Config, loc *time.Location, clk Clock) (time.Time, error) {
+	if cfg.ReplayStart == "" {
+		return clk.Now().In(loc), nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", cfg.ReplayStart, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("replay-start %q: %w", cfg.ReplayStart, err)
+	}
+	return t, nil
+}
+
+// startCapture — горутина чтения источника: кадры уходят в frames.
+// На первой ошибке (включая io.EOF) она сохраняется в a.captureErr и frames закрывается —
+// так потребитель сначала дочитывает все уже захваченные кадры, а потом видит причину.
+// У живого источника со сторожем (-watchdog) ошибки чтения, кроме io.EOF, не конец:
+// источник переоткрывается (watchdog.go).
+func (a *App) startCapture(ctx context.Context) <-chan captured {
+	frames := make(chan captured, 16)
+	a.captureDone = make(chan struct{})
+	var w *watchdog
+	if a.cfg.Watchdog > 0 && !a.waitWAV {
+		w = &watchdog{limit: a.cfg.Watchdog, flat: a.cfg.WatchdogFlat}
+	}
+	go func() {
+		defer close(a.captureDone)
+		defer close(frames)
+		for {
+			var fr audio.Frame
+			var err error
+			if w != nil {
+				fr, err = w.read(ctx, a.src)
+			} else {
+				fr, err = a.src.Read(ctx)
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				if w != nil && !errors.Is(err, io.EOF) {
+					if !a.recoverSource(ctx, frames, w, err) {
+						return
+					}
+					continue
+				}
+				a.captureErr = err
+				return
+			}
+			select {
+			case frames <- captured{fr: fr}:
+				continue
+			default:
+				// обработка отстала: пока ждём, живой источник может терять буферы
+				// (офлайн-источник всегда быстрее обработки — для него это норма)
+				if !a.waitWAV {
+					atomic.AddUint64(&a.stats.FrameQueueFull, 1)
+				}
+			}
+			if !sendCaptured(ctx, frames, captured{fr: fr}) {
+				return
+			}
+		}
+	}()
+	return frames
+}
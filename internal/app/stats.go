@@ -0,0 +1,108 @@
+// C:\_Projects_Go\AcousticLog\internal\app\stats.go
+
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	iofs "acousticlog/internal/io"
+	"acousticlog/internal/mathx"
+)
+
+// statsInterval — накопление статистики за текущее окно длиной d,
+// выровненное от полуночи (1h → 10:00–11:00, 15m → 10:15–10:30).
+type statsInterval struct {
+	d     time.Duration
+	label string
+	start time.Time
+	st    mathx.LevelStats
+}
+
+// parseIntervals — "1m,15m,1h" → длительности; пустая строка — статистика выключена.
+func parseIntervals(s string) ([]time.Duration, error) {
+	var out []time.Duration
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		d, err := parseInterval("stats-intervals", p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// parseInterval — длительность окна, выровненного от полуночи (flag — для текста ошибки).
+func parseInterval(flag, s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s %q: %w", flag, s, err)
+	}
+	if d < time.Second || (24*time.Hour)%d != 0 {
+		return 0, fmt.Errorf("%s %q: интервал должен делить сутки нацело и быть ≥ 1s", flag, s)
+	}
+	return d, nil
+}
+
+// intervalLabel — компактная запись длительности: 1m, 15m, 1h.
+func intervalLabel(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+func alignStart(t time.Time, d time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return midnight.Add(t.Sub(midnight) / d * d)
+}
+
+// flushIntervals — закрывает окна, закончившиеся к моменту now (force — все, включая текущие).
+// Вызывается до ротации, чтобы окно 23:00–24:00 попало в CSV своего дня.
+func (a *App) flushIntervals(now time.Time, force bool) {
+	for _, iv := range a.intervals {
+		if iv.st.Empty() {
+			continue
+		}
+		end := iv.start.Add(iv.d)
+		if !force && now.Before(end) {
+			continue
+		}
+		if force && now.Before(end) {
+			end = now // неполное окно при завершении
+		}
+		a.enqueueCSV(a.chStatsCSV, iofs.StatsRow{
+			Start: iv.start, End: end, Interval: iv.label,
+			Leq: iv.st.Leq(), Max: iv.st.Max(), Min: iv.st.Min(),
+			L10: iv.st.Percentile(10), L50: iv.st.Percentile(50), L90: iv.st.Percentile(90),
+			Measured: iv.st.Duration(),
+		}.Record())
+		iv.st.Reset()
+	}
+}
+
+// addIntervals — учёт буфера длительностью dt (с) во всех окнах.
+func (a *App) addIntervals(now time.Time, lv levels, dt float64) {
+	level := lv.lf
+	switch a.timeWeighting {
+	case mathx.TimeSlow:
+		level = lv.ls
+	case mathx.TimeImpulse:
+		level = lv.li
+	case mathx.TimeEq:
+		level = lv.leq
+	}
+	for _, iv := range a.intervals {
+		iv.start = alignStart(now, iv.d)
+		iv.st.Add(lv.leq, dt, level, lv.level)
+	}
+}
@@ -9,9 +9,19 @@ import (
 	"sync/atomic"
 	"time"
 
-	awin "acousticlog/internal/audio/winmm"
+	"acousticlog/internal/audio"
+	iofs "acousticlog/internal/io"
+	"acousticlog/internal/mathx"
 )
 
+// Clock — стенные часы программы: время сессии у живого источника, старт и темп
+// генератора -source synth, авто-остановка. Тесты подставляют свои, чтобы прогон
+// не зависел от часов машины и не ждал реального времени.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
 type AppStats struct {
 	BuffersProcessed uint64
 	CSVEventsWritten uint64
@@ -20,16 +30,30 @@ type AppStats struct {
 	WAVErrors        uint64
 	CSVErrors        uint64
 	DiskChecks       uint64
+	ClockResyncs     uint64
+	CaptureGaps      uint64 // разрывы захвата (звук потерян)
+	CaptureGapMs     uint64
+	WAVDropped       uint64 // клипы, не влезшие в очередь записи
+	CSVQueueFull     uint64 // ожидания писателя CSV
+	FrameQueueFull   uint64 // ожидания обработки кадров
+	DeviceLost       uint64 // срабатывания сторожа захвата
+	DeviceOpenErrors uint64 // неудачные попытки переоткрыть источник
+	ClippedBuffers   uint64 // буферы с перегрузкой входа
 }
 
 type App struct {
 	prevHour string
-	cfg *Config
+	cfg      *Config
 
 	// audio
-	Handle uintptr
-	Fmt    WAVEFORMATEX
-	Bufs   []*buffer
+	src         audio.Source
+	format      audio.Format
+	stopCapture func()
+	captureDone chan struct{}
+	captureErr  error        // причина остановки захвата; читать только после закрытия frames
+	waitWAV     bool         // офлайн-источник: chWAV блокирует, а не дропает
+	clock       *sampleClock // живой источник: время по счётчику сэмплов; nil — офлайн
+	lostAt      time.Time    // начало текущей потери устройства (сторож захвата)
 
 	// CSV
 	csvFile      *os.File
@@ -38,11 +62,19 @@ type App struct {
 	csvAllFile   *os.File
 	csvAllWriter *csv.Writer
 	csvAllPath   string
+	statsFile    *os.File
+	statsWriter  *csv.Writer
+	statsPath    string
+	statsHeader  []string
+	bandFile     *os.File // sound_bands; nil — полосовой анализ выключен
+	bandWriter   *csv.Writer
+	bandPath     string
+	bandHeader   []string
 
 	// dirs
+	layout     iofs.Layout
 	outDirRoot string
 	outDirCSV  string
-	outDirWAV  string
 
 	// disk
 	diskFreeMB     uint64
@@ -51,24 +83,40 @@ type App struct {
 	diskCheckMutex sync.Mutex
 
 	// time & limits
-	loc        *time.Location
-	splOffset  float64
-	dayLimit   float64
-	nightLimit float64
-	dayStart   int
-	dayEnd     int
+	wall      Clock
+	loc       *time.Location
+	splOffset float64
+	clipAt    int // модуль сэмпла, с которого вход считается перегруженным (-clip-level)
+	weighting *mathx.WeightingFilter
+
+	timeWeighter  *mathx.TimeWeighter
+	timeWeighting mathx.TimeWeighting
+	bandBank      *mathx.BandFilterBank // nil — -bands off
+	bandInterval  time.Duration
+	bandAcc       bandAccum
+	bandCheck     *bandCheck  // nil — нет порогов по полосам и НЧ-детектора
+	periods       []period    // отсортированы по началу; см. periods.go
+	schedule      *schedule   // nil — каждый день по periods
+	exclusions    []exclusion // окна исключения (-exclude)
+	inExclusion   bool        // прошлый буфер был в окне исключения
+	daily         dailyLevels // накопление Lday/Levening/Lnight за текущую дату
+	exceed        exceedGate
+	baseline      baseline   // фон для детектора импульсов
+	floor         noiseFloor // фон по часам суток (относительный режим)
+	events        eventTrack // встроенные детекторы; правила — в rules
+	rules         ruleSet
+	preRoll       pcmRing // последние -pre-roll секунд звука
 
 	// state
 	stopCh       chan struct{}
 	shutdownCh   chan struct{}
 	isShutting   atomic.Bool
-	prevDbSPL    float64
-	prevInit     bool
 	quiet        bool
 	linesPrinted int
 	nearMargin   float64
-	logAll       bool
 	csvDelim     rune
+	csvHeader    []string
+	eventHeader  []string // sound_log: строка на событие
 	impulseDelta float64
 
 	// live UI
@@ -78,31 +126,30 @@ type App struct {
 	liveWavDepth int
 
 	// pipelines
-	chMainCSV chan []string
-	chAllCSV  chan []string
-	chWAV     chan wavTask
-	wg        sync.WaitGroup
+	chMainCSV  chan []string
+	chAllCSV   chan []string
+	chWAV      chan wavTask
+	chStatsCSV chan []string
+	chBandCSV  chan []string
+	csvPending sync.WaitGroup // строки CSV в очередях; ротация ждёт их записи
+	wavPending sync.WaitGroup // клипы в очереди/записи; склейка часа ждёт их
+	wg         sync.WaitGroup
 
 	// rotation
 	currentDate string
 
+	// interval statistics (sound_stats)
+	intervals    []*statsInterval
+	lastFrameEnd time.Time
+
 	// stats
 	stats AppStats
 }
 
-// Алиасы ровно на типы пакета winmm (совместимость без кастов)
-type WAVEFORMATEX = awin.WAVEFORMATEX
-type WAVEHDR = awin.WAVEHDR
-
-type buffer struct {
-	mem []byte
-	hdr WAVEHDR
-}
-
 type wavTask struct {
-	when time.Time
-	rate int
-	pcm  []byte
-	kind string           // EXCEEDED | IMPULSE
-	after func(string)    // callback: receives saved WAV full path
+	when  time.Time
+	rate  int
+	pcm   []byte
+	kind  string       // EXCEEDED | IMPULSE
+	after func(string) // callback: receives saved WAV full path
 }
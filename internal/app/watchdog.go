@@ -0,0 +1,174 @@
+// C:\_Projects_Go\AcousticLog\internal\app\watchdog.go
+
+package app
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"acousticlog/internal/audio"
+	iofs "acousticlog/internal/io"
+	sysx "acousticlog/internal/sys"
+)
+
+// Состояния устройства в журналах (строки SYSTEM).
+const (
+	deviceLost     = "DEVICE_LOST"
+	deviceRestored = "DEVICE_RESTORED"
+)
+
+// Паузы между попытками переоткрыть источник: удваиваются до reopenMax и сбрасываются,
+// если после восстановления источник проработал не меньше reopenMax.
+const (
+	reopenMin = time.Second
+	reopenMax = time.Minute
+)
+
+var (
+	errNoBuffers = errors.New("нет буферов")
+	errFlat      = errors.New("сигнал постоянный (нули или залипшее значение)")
+)
+
+// captured — элемент очереди захвата: кадр или смена состояния устройства.
+// Одна очередь сохраняет порядок: кадры до потери, строка потери, кадры после.
+type captured struct {
+	fr     audio.Frame
+	device string // "" — кадр; deviceLost | deviceRestored
+	reason string
+	at     time.Time // потеря — момент последнего нормального кадра, восстановление — момент открытия
+}
+
+// watchdog — сторож живого захвата: нет буферов дольше -watchdog или они постоянные
+// дольше -watchdog-flat (микрофон выдернут, драйвер отдаёт тишину нулями) — источник
+// закрывается и переоткрывается с растущей паузой, пока не откроется.
+type watchdog struct {
+	limit     time.Duration
+	flat      time.Duration // 0 — постоянный сигнал потерей не считается
+	lastGood  time.Time     // конец последнего буфера с живым сигналом (по часам)
+	flatSince time.Time
+	backoff   time.Duration
+	restored  time.Time
+}
+
+// read — очередной кадр с таймаутом сторожа; errNoBuffers/errFlat — устройство потеряно.
+func (w *watchdog) read(ctx context.Context, src audio.Source) (audio.Frame, error) {
+	rctx, cancel := context.WithTimeout(ctx, w.limit)
+	fr, err := src.Read(rctx)
+	cancel()
+	now := time.Now()
+	if err != nil {
+		if ctx.Err() == nil && errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("%w %s", errNoBuffers, w.limit)
+		}
+		return fr, err
+	}
+	if w.flat <= 0 || !flatPCM(fr.PCM) {
+		w.lastGood, w.flatSince = now, time.Time{}
+		return fr, nil
+	}
+	if w.flatSince.IsZero() {
+		w.flatSince = now
+	}
+	if now.Sub(w.flatSince) >= w.flat {
+		return fr, fmt.Errorf("%w %s", errFlat, w.flat)
+	}
+	return fr, nil
+}
+
+// flatPCM — все сэмплы буфера одинаковы: живой микрофон так не пишет даже в тишине.
+func flatPCM(pcm []byte) bool {
+	if len(pcm) < 4 {
+		return false
+	}
+	first := binary.LittleEndian.Uint16(pcm)
+	for i := 2; i+1 < len(pcm); i += 2 {
+		if binary.LittleEndian.Uint16(pcm[i:]) != first {
+			return false
+		}
+	}
+	return true
+}
+
+// recoverSource — потеря устройства: строка в очередь, затем Close и Open с паузами,
+// пока не откроется или не отменён ctx (false — захват завершается).
+func (a *App) recoverSource(ctx context.Context, out chan<- captured, w *watchdog, cause error) bool {
+	since := w.lastGood
+	if since.IsZero() {
+		since = time.Now()
+	}
+	if !sendCaptured(ctx, out, captured{device: deviceLost, reason: cause.Error(), at: since}) {
+		return false
+	}
+	_ = a.src.Close()
+
+	if w.backoff == 0 || time.Since(w.restored) >= reopenMax {
+		w.backoff = reopenMin
+	}
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(w.backoff):
+		}
+		err := a.src.Open()
+		w.backoff = min(2*w.backoff, reopenMax)
+		if err == nil {
+			w.lastGood, w.flatSince, w.restored = time.Now(), time.Time{}, time.Now()
+			return sendCaptured(ctx, out, captured{device: deviceRestored,
+				reason: fmt.Sprintf("попытка %d", attempt), at: w.lastGood})
+		}
+		atomic.AddUint64(&a.stats.DeviceOpenErrors, 1)
+		fmt.Printf("%s[AUDIO WARNING] переоткрытие источника: %v — следующая попытка через %s%s\n",
+			sysx.ClrYellow, err, w.backoff, sysx.ClrReset)
+	}
+}
+
+func sendCaptured(ctx context.Context, out chan<- captured, c captured) bool {
+	select {
+	case out <- c:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// deviceEvent — смена состояния устройства в главном цикле: события закрываются на
+// потере (клип не должен склеить звук до и после), шкала времени привязывается заново,
+// а фильтры начинают с нуля — их состояние от звука до потери к новому не относится.
+func (a *App) deviceEvent(c captured) {
+	switch c.device {
+	case deviceLost:
+		atomic.AddUint64(&a.stats.DeviceLost, 1)
+		a.lostAt = c.at
+		a.markOpenEvents(iofs.QualityDeviceError)
+		for _, t := range a.tracks() {
+			a.closeEvent(t)
+		}
+		a.preRoll.keep(0)
+		a.logSystem(c.at, c.at, deviceLost, iofs.QualityDeviceError, c.reason, "NO_WAV")
+		fmt.Printf("\n%s[AUDIO ERROR] %s: %s — переоткрываем источник…%s\n", sysx.ClrRed, deviceLost, c.reason, sysx.ClrReset)
+	case deviceRestored:
+		if a.clock != nil {
+			a.clock.restart()
+		}
+		a.weighting.Reset()
+		a.timeWeighter.Reset()
+		if a.bandBank != nil {
+			a.bandBank.Reset()
+		}
+		for _, f := range a.rules.filters {
+			f.Reset()
+		}
+		start := a.lostAt
+		if start.IsZero() {
+			start = c.at
+		}
+		a.logSystem(start, c.at, deviceRestored, iofs.QualityDeviceError, c.reason, "NO_WAV")
+		fmt.Printf("\n%s[AUDIO] %s: нет звука %.1f с (%s)%s\n", sysx.ClrGreen, deviceRestored,
+			c.at.Sub(start).Seconds(), c.reason, sysx.ClrReset)
+	}
+}
@@ -0,0 +1,106 @@
+// C:\_Projects_Go\AcousticLog\internal\audio\pipe\format.go
+
+package pipe
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Форматы сэмплов, которые можно получить из arecord/ffmpeg/sox (raw, interleaved).
+const (
+	FormatS16LE = "s16le"
+	FormatS32LE = "s32le"
+	FormatF32LE = "f32le"
+	FormatU8    = "u8"
+)
+
+// sampleSize — байт на один сэмпл одного канала.
+func sampleSize(format string) (int, error) {
+	switch format {
+	case FormatS16LE:
+		return 2, nil
+	case FormatS32LE, FormatF32LE:
+		return 4, nil
+	case FormatU8:
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("pipe: unsupported sample format %q (s16le|s32le|f32le|u8)", format)
+	}
+}
+
+// toMono16 — raw interleaved → моно 16-бит LE (среднее каналов).
+func toMono16(raw []byte, format string, channels, size int) []byte {
+	block := size * channels
+	n := len(raw) / block
+	out := make([]byte, 2*n)
+	for i := 0; i < n; i++ {
+		var sum float64
+		for c := 0; c < channels; c++ {
+			sum += sampleAt(raw[i*block+c*size:], format)
+		}
+		v := sum / float64(channels) * 32768.0
+		if v > math.MaxInt16 {
+			v = math.MaxInt16
+		} else if v < math.MinInt16 {
+			v = math.MinInt16
+		}
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(int16(v)))
+	}
+	return out
+}
+
+// sampleAt — один сэмпл в диапазоне [-1, 1).
+func sampleAt(b []byte, format string) float64 {
+	switch format {
+	case FormatS16LE:
+		return float64(int16(binary.LittleEndian.Uint16(b))) / 32768.0
+	case FormatS32LE:
+		return float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648.0
+	case FormatF32LE:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	default: // u8
+		return (float64(b[0]) - 128) / 128.0
+	}
+}
+
+// splitCommand — разбивает командную строку на аргументы с учётом кавычек "..." и '...'.
+func splitCommand(s string) ([]string, error) {
+	var (
+		args  []string
+		cur   strings.Builder
+		quote rune
+		inArg bool
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("pipe: unterminated quote in %q", s)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,99 @@
+// C:\_Projects_Go\AcousticLog\internal\audio\pipe\format_test.go
+
+package pipe
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestToMono16(t *testing.T) {
+	s16 := func(v ...int16) []byte {
+		var b []byte
+		for _, s := range v {
+			b = binary.LittleEndian.AppendUint16(b, uint16(s))
+		}
+		return b
+	}
+	s32 := func(v ...int32) []byte {
+		var b []byte
+		for _, s := range v {
+			b = binary.LittleEndian.AppendUint32(b, uint32(s))
+		}
+		return b
+	}
+	f32 := func(v ...float32) []byte {
+		var b []byte
+		for _, s := range v {
+			b = binary.LittleEndian.AppendUint32(b, math.Float32bits(s))
+		}
+		return b
+	}
+	for _, tc := range []struct {
+		name     string
+		format   string
+		channels int
+		raw      []byte
+		want     []int16
+	}{
+		{"s16le mono", FormatS16LE, 1, s16(0, 1, -1, 32767, -32768), []int16{0, 1, -1, 32767, -32768}},
+		{"s16le stereo", FormatS16LE, 2, s16(100, 200, -100, -300, 32767, 32767), []int16{150, -200, 32767}},
+		{"s32le", FormatS32LE, 1, s32(1<<16, -1<<16, math.MaxInt32, math.MinInt32), []int16{1, -1, 32767, -32768}},
+		{"f32le", FormatF32LE, 1, f32(0, 0.5, -0.5, -1), []int16{0, 16384, -16384, -32768}},
+		{"f32le clipped", FormatF32LE, 1, f32(1.5, -2), []int16{32767, -32768}},
+		{"f32le stereo", FormatF32LE, 2, f32(0.5, 0, 1, 1), []int16{8192, 32767}},
+		{"u8", FormatU8, 1, []byte{0, 64, 128, 255}, []int16{-32768, -16384, 0, 32512}},
+		{"u8 stereo", FormatU8, 2, []byte{0, 255, 128, 192}, []int16{-128, 8192}},
+		{"partial block dropped", FormatS16LE, 2, s16(1, 2, 3), []int16{1}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			size, err := sampleSize(tc.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := toMono16(tc.raw, tc.format, tc.channels, size)
+			var got []int16
+			for i := 0; i+1 < len(out); i += 2 {
+				got = append(got, int16(binary.LittleEndian.Uint16(out[i:])))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := sampleSize("s24le"); err == nil {
+		t.Error("s24le: ожидалась ошибка")
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want []string // nil при ok — пустая команда
+		ok   bool
+	}{
+		{"arecord -q -f S16_LE", []string{"arecord", "-q", "-f", "S16_LE"}, true},
+		{"  ffmpeg\t-i   in.wav  ", []string{"ffmpeg", "-i", "in.wav"}, true},
+		{`sox "my file.wav" -t raw -`, []string{"sox", "my file.wav", "-t", "raw", "-"}, true},
+		{`sh -c 'echo "hi there"'`, []string{"sh", "-c", `echo "hi there"`}, true},
+		{`a "" b`, []string{"a", "", "b"}, true},
+		{`pre"fix a"post`, []string{"prefix apost"}, true},
+		{`C:\Tools\ffmpeg.exe -f dshow`, []string{`C:\Tools\ffmpeg.exe`, "-f", "dshow"}, true},
+		{"", nil, true},
+		{"   ", nil, true},
+		{`ffmpeg "-i`, nil, false},
+		{`sh -c 'oops`, nil, false},
+	} {
+		got, err := splitCommand(tc.in)
+		if (err == nil) != tc.ok {
+			t.Errorf("splitCommand(%q): ошибка %v, ожидалось ok=%v", tc.in, err, tc.ok)
+			continue
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("splitCommand(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+// C:\_Projects_Go\AcousticLog\internal\audio\pipe\source.go
+
+package pipe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"acousticlog/internal/audio"
+)
+
+const (
+	minBackoff = 1 * time.Second
+	maxBackoff = 30 * time.Second
+	// stableRun — если процесс проработал дольше, backoff сбрасывается к минимуму.
+	stableRun = time.Minute
+)
+
+// Config — параметры источника raw PCM.
+type Config struct {
+	Command    string // командная строка (arecord/ffmpeg/sox…); пусто или "-" — stdin
+	SampleRate int
+	Channels   int
+	Format     string // s16le | s32le | f32le | u8
+	BufferMs   int
+}
+
+// Source — raw PCM из stdin или из stdout дочернего процесса.
+// Упавший процесс перезапускается с экспоненциальной задержкой; конец stdin — io.EOF.
+type Source struct {
+	cfg   Config
+	size  int // байт на сэмпл одного канала
+	chunk int // байт на буфер (все каналы)
+	args  []string
+
+	in     *stream // чтение stdin: одно на все Open, прервать его нельзя
+	st     *stream // текущий Open
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// stream — кадры одного Open. Канал закрывается, когда поток кончился, а причина
+// записывается до закрытия: Read отдаёт её только после всех буферизованных кадров,
+// поэтому хвост stdin не теряется.
+type stream struct {
+	frames chan audio.Frame
+	err    error
+}
+
+func (st *stream) finish(err error) {
+	st.err = err
+	close(st.frames)
+}
+
+var _ audio.Source = (*Source)(nil)
+
+func NewSource(cfg Config) *Source {
+	return &Source{cfg: cfg}
+}
+
+func (s *Source) Format() audio.Format { return audio.Mono16(s.cfg.SampleRate) }
+
+func (s *Source) stdin() bool {
+	c := strings.TrimSpace(s.cfg.Command)
+	return c == "" || c == "-"
+}
+
+func (s *Source) Open() error {
+	size, err := sampleSize(strings.ToLower(s.cfg.Format))
+	if err != nil {
+		return err
+	}
+	if s.cfg.Channels < 1 || s.cfg.SampleRate <= 0 {
+		return fmt.Errorf("pipe: bad channels/samplerate: %d/%d", s.cfg.Channels, s.cfg.SampleRate)
+	}
+	s.cfg.Format = strings.ToLower(s.cfg.Format)
+	s.size = size
+	frames := s.cfg.SampleRate * s.cfg.BufferMs / 1000
+	if frames < 1 {
+		frames = 1
+	}
+	s.chunk = frames * size * s.cfg.Channels
+
+	st := &stream{frames: make(chan audio.Frame, 4)}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	if s.stdin() {
+		// после Close горутина чтения остаётся висеть в ReadFull; вторая читала бы stdin
+		// наперегонки с ней, теряла куски и сбивала выравнивание сэмплов
+		if s.in == nil {
+			s.in = &stream{frames: make(chan audio.Frame, 4)}
+			go func() { s.in.finish(s.pump(context.Background(), os.Stdin, s.in)) }()
+		}
+		s.st, s.cancel, s.done = st, cancel, make(chan struct{})
+		go func() {
+			defer close(s.done)
+			st.finish(s.relay(ctx, st))
+		}()
+		return nil
+	}
+
+	if s.args, err = splitCommand(s.cfg.Command); err != nil {
+		cancel()
+		return err
+	}
+	if len(s.args) == 0 {
+		cancel()
+		return errors.New("pipe: empty command")
+	}
+	// первый запуск синхронно: опечатка в команде должна проявиться сразу
+	cmd, out, stderr, err := s.start(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	// источник считается открытым только после успешного запуска: иначе Close ждал бы
+	// горутину, которой нет
+	s.st, s.cancel, s.done = st, cancel, make(chan struct{})
+	go s.supervise(ctx, st, cmd, out, stderr)
+	return nil
+}
+
+// start — запуск дочернего процесса; stdout — поток PCM, stderr — в хвостовой буфер.
+func (s *Source) start(ctx context.Context) (*exec.Cmd, io.ReadCloser, *tailBuffer, error) {
+	cmd := exec.CommandContext(ctx, s.args[0], s.args[1:]...)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	stderr := &tailBuffer{max: 512}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, nil, nil, fmt.Errorf("pipe: start %q: %w", s.args[0], err)
+	}
+	return cmd, out, stderr, nil
+}
+
+// supervise — читает текущий процесс и перезапускает его после выхода, пока источник не закрыт.
+func (s *Source) supervise(ctx context.Context, st *stream, cmd *exec.Cmd, out io.ReadCloser, stderr *tailBuffer) {
+	defer close(s.done)
+	defer func() { st.finish(ctx.Err()) }()
+	backoff := minBackoff
+	for {
+		if cmd != nil {
+			started := time.Now()
+			rerr := s.pump(ctx, out, st)
+			werr := cmd.Wait()
+			if ctx.Err() != nil {
+				return
+			}
+			if time.Since(started) >= stableRun {
+				backoff = minBackoff
+			}
+			reason := werr
+			if reason == nil {
+				reason = rerr
+			}
+			log.Printf("[pipe] %q завершился: %v %s— перезапуск через %s",
+				s.args[0], reason, stderr.last(), backoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+
+		var err error
+		cmd, out, stderr, err = s.start(ctx)
+		if err != nil {
+			log.Printf("[pipe] %v — следующая попытка через %s", err, backoff)
+			cmd = nil
+		}
+	}
+}
+
+// pump — нарезает поток на буферы по BufferMs и отдаёт их в frames.
+func (s *Source) pump(ctx context.Context, r io.Reader, st *stream) error {
+	for {
+		raw := make([]byte, s.chunk)
+		if _, err := io.ReadFull(r, raw); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				err = io.EOF // хвост короче буфера отбрасываем
+			}
+			return err
+		}
+		pcm := toMono16(raw, s.cfg.Format, s.cfg.Channels, s.size)
+		// буфер дочитан — значит, он только что кончился: начало на его длительность раньше
+		fr := audio.Frame{PCM: pcm, When: time.Now().Add(-s.Format().Duration(len(pcm)))}
+		select {
+		case st.frames <- fr:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// relay — кадры общего чтения stdin в поток текущего Open.
+func (s *Source) relay(ctx context.Context, st *stream) error {
+	for {
+		select {
+		case fr, ok := <-s.in.frames:
+			if !ok {
+				return s.in.err
+			}
+			select {
+			case st.frames <- fr:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Source) Read(ctx context.Context) (audio.Frame, error) {
+	if s.st == nil {
+		return audio.Frame{}, audio.ErrNotOpen
+	}
+	select {
+	case fr, ok := <-s.st.frames:
+		if !ok {
+			return audio.Frame{}, s.st.err
+		}
+		return fr, nil
+	case <-ctx.Done():
+		return audio.Frame{}, ctx.Err()
+	}
+}
+
+// Close — останавливает процесс или передачу кадров stdin; само чтение stdin продолжается
+// и достанется следующему Open.
+func (s *Source) Close() error {
+	if s.cancel == nil {
+		return nil
+	}
+	s.cancel()
+	s.cancel = nil
+	<-s.done
+	return nil
+}
+
+// tailBuffer — хранит последние max байт stderr процесса для диагностики.
+type tailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = t.buf[len(t.buf)-t.max:]
+	}
+	return len(p), nil
+}
+
+// last — последняя непустая строка stderr в виде "(…) " или пустая строка.
+func (t *tailBuffer) last() string {
+	if t == nil {
+		return ""
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := strings.Split(strings.TrimSpace(string(t.buf)), "\n")
+	if l := strings.TrimSpace(lines[len(lines)-1]); l != "" {
+		return "(" + l + ") "
+	}
+	return ""
+}
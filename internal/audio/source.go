@@ -0,0 +1,73 @@
+// C:\_Projects_Go\AcousticLog\internal\audio\source.go
+
+package audio
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Format — формат PCM, который источник отдаёт в Frame.PCM.
+// Конвейер App работает с моно 16-бит LE, поэтому источники приводят данные к нему сами.
+type Format struct {
+	SampleRate    int
+	Channels      int
+	BitsPerSample int
+}
+
+// Mono16 — стандартный формат конвейера: моно, 16 бит.
+func Mono16(sampleRate int) Format {
+	return Format{SampleRate: sampleRate, Channels: 1, BitsPerSample: 16}
+}
+
+// BytesPerSec — байт в секунду для данного формата.
+func (f Format) BytesPerSec() int {
+	return f.SampleRate * f.Channels * f.BitsPerSample / 8
+}
+
+// Duration — длительность n байт PCM в этом формате.
+func (f Format) Duration(n int) time.Duration {
+	if f.BytesPerSec() <= 0 {
+		return 0
+	}
+	return time.Duration(float64(n) / float64(f.BytesPerSec()) * float64(time.Second))
+}
+
+// Frame — один захваченный буфер PCM и момент, к которому он относится.
+type Frame struct {
+	PCM  []byte    // моно 16-бит LE; принадлежит получателю
+	When time.Time // время начала буфера
+}
+
+// Source — источник звука для App: WinMM, файл, внешняя команда, генератор и т.д.
+//
+// Read блокируется до готовности очередного буфера или отмены ctx.
+// Конец данных (например, конец файла) сообщается ошибкой io.EOF.
+// Read и Close не вызываются конкурентно.
+type Source interface {
+	Open() error
+	Read(ctx context.Context) (Frame, error)
+	Close() error
+	Format() Format
+}
+
+// ErrNotOpen — Read/Close вызваны до Open.
+var ErrNotOpen = errors.New("audio source is not open")
+
+// BufferBytes — размер буфера в байтах для формата и длительности в мс (не меньше 512).
+func BufferBytes(f Format, bufferMs int) int {
+	size := f.BytesPerSec() / 1000 * bufferMs
+	if size < 512 {
+		size = 512
+	}
+	return size
+}
+
+// Offline — источник со своей шкалой времени (файл, генератор), а не живой захват.
+// StartTime — момент, к которому относится первый кадр. Для таких источников App
+// берёт дату каталогов из StartTime, не теряет WAV-задачи при заполненной очереди
+// (ждёт диск) и не применяет -stop-at.
+type Offline interface {
+	StartTime() time.Time
+}
@@ -0,0 +1,129 @@
+// C:\_Projects_Go\AcousticLog\internal\audio\synth\scenario.go
+
+package synth
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Виды шагов сценария.
+const (
+	KindSilence = "silence" // цифровой ноль
+	KindPink    = "pink"    // розовый шум, level — RMS
+	KindTone    = "tone"    // синус freq, level — RMS
+	KindBurst   = "burst"   // пачки тона: on/off
+	KindImpulse = "impulse" // щелчки в моменты at, level — пик
+)
+
+// Step — один шаг сценария. Уровни хранятся в dBFS.
+type Step struct {
+	Kind       string
+	Duration   time.Duration
+	Level      float64
+	Freq       float64
+	On, Off    time.Duration   // burst
+	At         []time.Duration // impulse: смещения от начала шага
+	Width      time.Duration   // impulse: длительность затухания
+	Background float64         // розовый шум под тоном/импульсами, dBFS; 0 — без фона
+}
+
+// Scenario — последовательность шагов; генератор проигрывает их подряд.
+type Scenario []Step
+
+// Duration — общая длительность сценария.
+func (sc Scenario) Duration() time.Duration {
+	var d time.Duration
+	for _, st := range sc {
+		d += st.Duration
+	}
+	return d
+}
+
+// ParseScenario — разбор сценария вида
+//
+//	"silence 2s; pink 30s level=60; tone 3s level=70 freq=1000 bg=40;
+//	 burst 10s level=75 freq=500 on=200ms off=800ms; impulse 5s level=95 at=1s,2.5s width=20ms bg=40"
+//
+// Уровни (level, bg) задаются в дБ шкалы приложения и переводятся в dBFS вычитанием
+// levelOffset (обычно -spl-offset), чтобы "pink level=60" давал 60 в колонке dB_SPL
+// без частотной коррекции (-weighting Z; тон 1 кГц — при любой коррекции).
+func ParseScenario(s string, levelOffset float64) (Scenario, error) {
+	var sc Scenario
+	for _, part := range strings.Split(s, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("synth: step %q: need <kind> <duration>", strings.TrimSpace(part))
+		}
+		st := Step{Kind: strings.ToLower(fields[0]), Freq: 1000, Width: 10 * time.Millisecond}
+		d, err := time.ParseDuration(fields[1])
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("synth: step %q: bad duration %q", st.Kind, fields[1])
+		}
+		st.Duration = d
+
+		haveLevel := false
+		for _, kv := range fields[2:] {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok {
+				return nil, fmt.Errorf("synth: step %q: expected key=value, got %q", st.Kind, kv)
+			}
+			switch strings.ToLower(k) {
+			case "level":
+				st.Level, err = strconv.ParseFloat(v, 64)
+				st.Level -= levelOffset
+				haveLevel = true
+			case "bg":
+				st.Background, err = strconv.ParseFloat(v, 64)
+				st.Background -= levelOffset
+			case "freq":
+				st.Freq, err = strconv.ParseFloat(v, 64)
+			case "on":
+				st.On, err = time.ParseDuration(v)
+			case "off":
+				st.Off, err = time.ParseDuration(v)
+			case "width":
+				st.Width, err = time.ParseDuration(v)
+			case "at":
+				for _, a := range strings.Split(v, ",") {
+					var off time.Duration
+					if off, err = time.ParseDuration(a); err != nil {
+						break
+					}
+					st.At = append(st.At, off)
+				}
+				sort.Slice(st.At, func(i, j int) bool { return st.At[i] < st.At[j] })
+			default:
+				err = fmt.Errorf("unknown key %q", k)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("synth: step %q: %s: %w", st.Kind, kv, err)
+			}
+		}
+
+		switch st.Kind {
+		case KindSilence:
+		case KindPink, KindTone, KindImpulse:
+			if !haveLevel {
+				return nil, fmt.Errorf("synth: step %q: level= is required", st.Kind)
+			}
+		case KindBurst:
+			if !haveLevel || st.On <= 0 || st.Off < 0 {
+				return nil, fmt.Errorf("synth: step %q: level= and on= are required", st.Kind)
+			}
+		default:
+			return nil, fmt.Errorf("synth: unknown step kind %q", st.Kind)
+		}
+		sc = append(sc, st)
+	}
+	if len(sc) == 0 {
+		return nil, fmt.Errorf("synth: empty scenario")
+	}
+	return sc, nil
+}
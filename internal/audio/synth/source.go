@@ -0,0 +1,266 @@
+// C:\_Projects_Go\AcousticLog\internal\audio\synth\source.go
+
+package synth
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+
+	"acousticlog/internal/audio"
+)
+
+// Clock — время генератора; по умолчанию системное, в тестах подменяется.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// SystemClock — реальное время.
+var SystemClock Clock = systemClock{}
+
+// Options — параметры генератора.
+type Options struct {
+	SampleRate int
+	BufferMs   int
+	Start      time.Time // время первого сэмпла; нулевое — Clock.Now() при создании
+	Realtime   bool      // отдавать буферы в темпе реального времени по Clock
+	Seed       int64     // seed шума: одинаковый seed — одинаковые сэмплы
+	Clock      Clock     // nil — SystemClock
+}
+
+// Source — детерминированный генератор сценария (audio.Offline): метки времени
+// считаются от Start по номеру сэмпла, после конца сценария Read возвращает io.EOF.
+type Source struct {
+	sc   Scenario
+	opts Options
+
+	ends  []int64 // номер сэмпла конца каждого шага
+	pos   int64
+	open  bool
+	wall  time.Time
+	gen   *stepGen
+	genIx int
+}
+
+var (
+	_ audio.Source  = (*Source)(nil)
+	_ audio.Offline = (*Source)(nil)
+)
+
+func NewSource(sc Scenario, opts Options) *Source {
+	if opts.Clock == nil {
+		opts.Clock = SystemClock
+	}
+	if opts.Start.IsZero() {
+		opts.Start = opts.Clock.Now()
+	}
+	s := &Source{sc: sc, opts: opts}
+	var end int64
+	for _, st := range sc {
+		end += durationToSamples(st.Duration, opts.SampleRate)
+		s.ends = append(s.ends, end)
+	}
+	return s
+}
+
+func (s *Source) StartTime() time.Time { return s.opts.Start }
+func (s *Source) Format() audio.Format { return audio.Mono16(s.opts.SampleRate) }
+
+func (s *Source) Open() error {
+	s.pos, s.genIx, s.gen = 0, -1, nil
+	s.wall = s.opts.Clock.Now()
+	s.open = true
+	return nil
+}
+
+func (s *Source) Close() error {
+	s.open = false
+	return nil
+}
+
+func (s *Source) Read(ctx context.Context) (audio.Frame, error) {
+	if !s.open {
+		return audio.Frame{}, audio.ErrNotOpen
+	}
+	if err := ctx.Err(); err != nil {
+		return audio.Frame{}, err
+	}
+	total := s.ends[len(s.ends)-1]
+	if s.pos >= total {
+		return audio.Frame{}, io.EOF
+	}
+	n := int64(s.opts.SampleRate * s.opts.BufferMs / 1000)
+	if n < 1 {
+		n = 1
+	}
+	if s.pos+n > total {
+		n = total - s.pos
+	}
+
+	when := s.opts.Start.Add(samplesToDuration(s.pos, s.opts.SampleRate))
+	pcm := make([]byte, 2*n)
+	for i := int64(0); i < n; i++ {
+		v := s.sample(s.pos + i)
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(toInt16(v)))
+	}
+	s.pos += n
+
+	if s.opts.Realtime {
+		due := s.wall.Add(samplesToDuration(s.pos, s.opts.SampleRate))
+		if d := due.Sub(s.opts.Clock.Now()); d > 0 {
+			select {
+			case <-ctx.Done():
+				return audio.Frame{}, ctx.Err()
+			case <-s.opts.Clock.After(d):
+			}
+		}
+	}
+	return audio.Frame{PCM: pcm, When: when}, nil
+}
+
+// sample — значение сэмпла с глобальным номером i в диапазоне [-1, 1].
+func (s *Source) sample(i int64) float64 {
+	ix := s.genIx
+	if ix < 0 || i >= s.ends[ix] {
+		ix = 0
+		for ix < len(s.ends)-1 && i >= s.ends[ix] {
+			ix++
+		}
+	}
+	if ix != s.genIx {
+		var begin int64
+		if ix > 0 {
+			begin = s.ends[ix-1]
+		}
+		// seed зависит от номера шага — каждый шаг воспроизводим независимо
+		s.gen = newStepGen(s.sc[ix], s.opts.SampleRate, begin, s.opts.Seed+int64(ix)*7919)
+		s.genIx = ix
+	}
+	return s.gen.at(i)
+}
+
+// stepGen — состояние генерации одного шага.
+type stepGen struct {
+	st    Step
+	rate  float64
+	begin int64
+
+	rng   *rand.Rand
+	pink  pinkFilter
+	bg    pinkFilter
+	pinkK float64 // масштаб розового шума до нужного RMS
+	amp   float64 // амплитуда тона / пик импульса
+	bgK   float64 // масштаб фонового розового шума
+}
+
+func newStepGen(st Step, rate int, begin int64, seed int64) *stepGen {
+	g := &stepGen{st: st, rate: float64(rate), begin: begin, rng: rand.New(rand.NewSource(seed))}
+	unit := pinkUnitRMS(rate, seed)
+	switch st.Kind {
+	case KindPink:
+		g.pinkK = dbToLin(st.Level) / unit
+	case KindTone, KindBurst:
+		g.amp = dbToLin(st.Level) * math.Sqrt2
+	case KindImpulse:
+		g.amp = dbToLin(st.Level)
+	}
+	if st.Background != 0 {
+		g.bgK = dbToLin(st.Background) / unit
+	}
+	return g
+}
+
+func (g *stepGen) at(i int64) float64 {
+	t := float64(i-g.begin) / g.rate
+	var v float64
+	switch g.st.Kind {
+	case KindPink:
+		v = g.pinkK * g.pink.next(g.rng)
+	case KindTone:
+		v = g.amp * math.Sin(2*math.Pi*g.st.Freq*t)
+	case KindBurst:
+		period := (g.st.On + g.st.Off).Seconds()
+		if math.Mod(t, period) < g.st.On.Seconds() {
+			v = g.amp * math.Sin(2*math.Pi*g.st.Freq*t)
+		}
+	case KindImpulse:
+		w := g.st.Width.Seconds()
+		for _, at := range g.st.At {
+			dt := t - at.Seconds()
+			if dt >= 0 && dt < w {
+				// затухающий широкополосный щелчок; знак чередуется каждый сэмпл
+				sign := 1.0
+				if (i-g.begin)%2 == 1 {
+					sign = -1
+				}
+				v += sign * g.amp * math.Exp(-5*dt/w)
+			}
+		}
+	}
+	if g.bgK != 0 {
+		v += g.bgK * g.bg.next(g.rng)
+	}
+	return v
+}
+
+// pinkFilter — фильтр Пола Келлетта (white → pink, -3 дБ/октава).
+type pinkFilter struct{ b0, b1, b2, b3, b4, b5, b6 float64 }
+
+func (p *pinkFilter) next(rng *rand.Rand) float64 {
+	w := rng.Float64()*2 - 1
+	p.b0 = 0.99886*p.b0 + w*0.0555179
+	p.b1 = 0.99332*p.b1 + w*0.0750759
+	p.b2 = 0.96900*p.b2 + w*0.1538520
+	p.b3 = 0.86650*p.b3 + w*0.3104856
+	p.b4 = 0.55000*p.b4 + w*0.5329522
+	p.b5 = -0.7616*p.b5 - w*0.0168980
+	out := p.b0 + p.b1 + p.b2 + p.b3 + p.b4 + p.b5 + p.b6 + w*0.5362
+	p.b6 = w * 0.115926
+	return out
+}
+
+// pinkUnitRMS — RMS нефильтрованного (без масштаба) розового шума: меряем на 2 с
+// отдельного генератора, чтобы уровень level= соблюдался точно.
+func pinkUnitRMS(rate int, seed int64) float64 {
+	rng := rand.New(rand.NewSource(seed ^ 0x5eed))
+	var p pinkFilter
+	n := 2 * rate
+	var sum float64
+	for i := 0; i < n; i++ {
+		v := p.next(rng)
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(n))
+}
+
+func dbToLin(db float64) float64 { return math.Pow(10, db/20) }
+
+func toInt16(v float64) int16 {
+	x := math.Round(v * 32768)
+	if x > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if x < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(x)
+}
+
+func durationToSamples(d time.Duration, rate int) int64 {
+	r := int64(rate)
+	return int64(d/time.Second)*r + int64(d%time.Second)*r/int64(time.Second)
+}
+
+func samplesToDuration(n int64, rate int) time.Duration {
+	r := int64(rate)
+	return time.Duration(n/r)*time.Second + time.Duration(n%r)*time.Second/time.Duration(r)
+}
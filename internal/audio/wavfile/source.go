@@ -0,0 +1,210 @@
+// C:\_Projects_Go\AcousticLog\internal\audio\wavfile\source.go
+
+package wavfile
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"acousticlog/internal/audio"
+)
+
+const (
+	formatPCM        = 1
+	formatExtensible = 0xFFFE
+)
+
+var ErrUnsupportedFormat = errors.New("wavfile: only 16-bit PCM mono/stereo is supported")
+
+// Source — воспроизведение готового WAV (16 бит, моно/стерео) как источника звука.
+// Стерео сводится в моно; время кадров = start + позиция в файле.
+// realtime=true — кадры отдаются в темпе записи, иначе — так быстро, как успевает конвейер.
+type Source struct {
+	path     string
+	start    time.Time
+	bufferMs int
+	realtime bool
+
+	f         *os.File
+	r         *bufio.Reader
+	rate      int
+	channels  int
+	left      int64 // байт, оставшихся в чанке data
+	pos       int64 // отдано сэмплов (на канал)
+	wallStart time.Time
+}
+
+var (
+	_ audio.Source  = (*Source)(nil)
+	_ audio.Offline = (*Source)(nil)
+)
+
+func NewSource(path string, start time.Time, bufferMs int, realtime bool) *Source {
+	return &Source{path: path, start: start, bufferMs: bufferMs, realtime: realtime}
+}
+
+func (s *Source) StartTime() time.Time { return s.start }
+
+// Format — известен только после Open (берётся из заголовка файла).
+func (s *Source) Format() audio.Format { return audio.Mono16(s.rate) }
+
+func (s *Source) Open() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	r := bufio.NewReaderSize(f, 64*1024)
+	if err := s.readHeader(r); err != nil {
+		f.Close()
+		return fmt.Errorf("%s: %w", s.path, err)
+	}
+	s.f, s.r = f, r
+	s.pos = 0
+	s.wallStart = time.Now()
+	return nil
+}
+
+// readHeader — проходит чанки RIFF до начала data; fmt должен идти раньше data.
+func (s *Source) readHeader(r io.Reader) error {
+	var hdr [12]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return err
+	}
+	if string(hdr[0:4]) != "RIFF" || string(hdr[8:12]) != "WAVE" {
+		return errors.New("not a RIFF/WAVE file")
+	}
+	haveFmt := false
+	for {
+		var ch [8]byte
+		if _, err := io.ReadFull(r, ch[:]); err != nil {
+			if errors.Is(err, io.EOF) {
+				return errors.New("missing data chunk")
+			}
+			return err
+		}
+		tag := string(ch[0:4])
+		size := int64(binary.LittleEndian.Uint32(ch[4:8]))
+		switch tag {
+		case "fmt ":
+			if size < 16 {
+				return errors.New("short fmt chunk")
+			}
+			buf := make([]byte, size+size%2)
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return err
+			}
+			tagFmt := binary.LittleEndian.Uint16(buf[0:2])
+			s.channels = int(binary.LittleEndian.Uint16(buf[2:4]))
+			s.rate = int(binary.LittleEndian.Uint32(buf[4:8]))
+			bits := binary.LittleEndian.Uint16(buf[14:16])
+			if (tagFmt != formatPCM && tagFmt != formatExtensible) || bits != 16 ||
+				s.channels < 1 || s.channels > 2 || s.rate <= 0 {
+				return ErrUnsupportedFormat
+			}
+			haveFmt = true
+		case "data":
+			if !haveFmt {
+				return errors.New("data chunk before fmt chunk")
+			}
+			s.left = size
+			return nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, size+size%2); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Source) Read(ctx context.Context) (audio.Frame, error) {
+	if s.f == nil {
+		return audio.Frame{}, audio.ErrNotOpen
+	}
+	if err := ctx.Err(); err != nil {
+		return audio.Frame{}, err
+	}
+	if s.left <= 0 {
+		return audio.Frame{}, io.EOF
+	}
+
+	block := 2 * s.channels
+	want := int64(s.rate*s.bufferMs/1000) * int64(block)
+	if want < int64(block) {
+		want = int64(block)
+	}
+	if want > s.left {
+		want = s.left - s.left%int64(block)
+	}
+	raw := make([]byte, want)
+	n, err := io.ReadFull(s.r, raw)
+	n -= n % block
+	if n == 0 {
+		s.left = 0
+		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return audio.Frame{}, err
+		}
+		return audio.Frame{}, io.EOF
+	}
+	s.left -= int64(n)
+	if err != nil {
+		// файл короче, чем заявлено в заголовке — отдаём то, что есть
+		s.left = 0
+	}
+
+	pcm := downmix(raw[:n], s.channels)
+	frames := int64(n / block)
+	when := s.start.Add(samplesToDuration(s.pos, s.rate))
+	s.pos += frames
+
+	if s.realtime {
+		due := s.wallStart.Add(samplesToDuration(s.pos, s.rate))
+		if d := time.Until(due); d > 0 {
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return audio.Frame{}, ctx.Err()
+			case <-t.C:
+			}
+		}
+	}
+	return audio.Frame{PCM: pcm, When: when}, nil
+}
+
+func (s *Source) Close() error {
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f, s.r = nil, nil
+	return err
+}
+
+// downmix — стерео 16 бит → моно (среднее каналов); моно возвращается как есть.
+func downmix(raw []byte, channels int) []byte {
+	if channels == 1 {
+		return raw
+	}
+	n := len(raw) / (2 * channels)
+	out := make([]byte, 2*n)
+	for i := 0; i < n; i++ {
+		var sum int32
+		for c := 0; c < channels; c++ {
+			off := (i*channels + c) * 2
+			sum += int32(int16(binary.LittleEndian.Uint16(raw[off:])))
+		}
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(int16(sum/int32(channels))))
+	}
+	return out
+}
+
+func samplesToDuration(n int64, rate int) time.Duration {
+	r := int64(rate)
+	return time.Duration(n/r)*time.Second + time.Duration(n%r)*time.Second/time.Duration(r)
+}
@@ -0,0 +1,168 @@
+// C:\_Projects_Go\AcousticLog\internal\audio\wavfile\source_test.go
+
+package wavfile
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chunk — чанк RIFF; нечётный размер дополняется байтом, как в файле.
+func chunk(tag string, body []byte) []byte {
+	b := append([]byte(tag), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	b = append(b, body...)
+	if len(body)%2 == 1 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// fmtChunk — тело fmt: тег формата, каналы, частота, бит на сэмпл (+ extra байт расширения).
+func fmtChunk(tag, channels uint16, rate uint32, bits uint16, extra int) []byte {
+	b := binary.LittleEndian.AppendUint16(nil, tag)
+	b = binary.LittleEndian.AppendUint16(b, channels)
+	b = binary.LittleEndian.AppendUint32(b, rate)
+	b = binary.LittleEndian.AppendUint32(b, rate*uint32(channels)*uint32(bits)/8)
+	b = binary.LittleEndian.AppendUint16(b, channels*bits/8)
+	b = binary.LittleEndian.AppendUint16(b, bits)
+	return chunk("fmt ", append(b, make([]byte, extra)...))
+}
+
+func samples16(v ...int16) []byte {
+	var b []byte
+	for _, s := range v {
+		b = binary.LittleEndian.AppendUint16(b, uint16(s))
+	}
+	return b
+}
+
+func riff(chunks ...[]byte) []byte {
+	body := append([]byte("WAVE"), bytes.Join(chunks, nil)...)
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+// readAll — Open и все кадры до io.EOF: моно PCM подряд и время каждого кадра.
+func readAll(t *testing.T, data []byte, bufferMs int) (*Source, []int16, []time.Time, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "in.wav")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)
+	s := NewSource(path, start, bufferMs, false)
+	if err := s.Open(); err != nil {
+		return s, nil, nil, err
+	}
+	defer s.Close()
+	var pcm []int16
+	var when []time.Time
+	for {
+		fr, err := s.Read(context.Background())
+		if errors.Is(err, io.EOF) {
+			return s, pcm, when, nil
+		}
+		if err != nil {
+			return s, pcm, when, err
+		}
+		for i := 0; i+1 < len(fr.PCM); i += 2 {
+			pcm = append(pcm, int16(binary.LittleEndian.Uint16(fr.PCM[i:])))
+		}
+		when = append(when, fr.When)
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	mono := fmtChunk(formatPCM, 1, 1000, 16, 0)
+	data := chunk("data", samples16(1, 2, 3, 4, 5))
+	for _, tc := range []struct {
+		name     string
+		file     []byte
+		rate     int
+		channels int
+		pcm      []int16
+		err      string // подстрока ошибки Open; "" — без ошибки
+	}{
+		{"mono", riff(mono, data), 1000, 1, []int16{1, 2, 3, 4, 5}, ""},
+		{"stereo downmix", riff(fmtChunk(formatPCM, 2, 1000, 16, 0), chunk("data", samples16(10, 20, -3, 0, 32767, 32767))),
+			1000, 2, []int16{15, -1, 32767}, ""},
+		{"odd unknown chunk is padded", riff(chunk("LIST", []byte("abc")), mono, data), 1000, 1, []int16{1, 2, 3, 4, 5}, ""},
+		{"unknown chunks around fmt", riff(chunk("JUNK", make([]byte, 10)), mono, chunk("fact", []byte{1, 0, 0, 0}), data),
+			1000, 1, []int16{1, 2, 3, 4, 5}, ""},
+		{"extensible fmt", riff(fmtChunk(formatExtensible, 1, 1000, 16, 24), data), 1000, 1, []int16{1, 2, 3, 4, 5}, ""},
+		{"odd fmt size", riff(fmtChunk(formatPCM, 1, 1000, 16, 1), data), 1000, 1, []int16{1, 2, 3, 4, 5}, ""},
+		{"data shorter than header", riff(mono, chunk("data", samples16(1, 2, 3, 4, 5, 6, 7, 8)))[:44+6], 1000, 1, []int16{1, 2, 3}, ""},
+		{"missing fmt", riff(data), 0, 0, nil, "data chunk before fmt chunk"},
+		{"missing data", riff(mono), 0, 0, nil, "missing data chunk"},
+		{"not RIFF", append([]byte("RIFX"), riff(mono, data)[4:]...), 0, 0, nil, "not a RIFF/WAVE file"},
+		{"short fmt", riff(chunk("fmt ", make([]byte, 14)), data), 0, 0, nil, "short fmt chunk"},
+		{"8-bit", riff(fmtChunk(formatPCM, 1, 1000, 8, 0), data), 0, 0, nil, ErrUnsupportedFormat.Error()},
+		{"3 channels", riff(fmtChunk(formatPCM, 3, 1000, 16, 0), data), 0, 0, nil, ErrUnsupportedFormat.Error()},
+		{"float", riff(fmtChunk(3, 1, 1000, 16, 0), data), 0, 0, nil, ErrUnsupportedFormat.Error()},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s, pcm, _, err := readAll(t, tc.file, 2)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("ошибка %v, ожидалась %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.rate != tc.rate || s.channels != tc.channels {
+				t.Errorf("rate/channels %d/%d, want %d/%d", s.rate, s.channels, tc.rate, tc.channels)
+			}
+			if !slices.Equal(pcm, tc.pcm) {
+				t.Errorf("PCM %v, want %v", pcm, tc.pcm)
+			}
+		})
+	}
+}
+
+// Время кадра — начало записи плюс позиция в файле, буферы по -buffer-ms.
+func TestReadFrameTimes(t *testing.T) {
+	v := make([]int16, 2500)
+	_, pcm, when, err := readAll(t, riff(fmtChunk(formatPCM, 2, 1000, 16, 0), chunk("data", samples16(v...))), 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 1250 {
+		t.Errorf("сэмплов %d, want 1250", len(pcm))
+	}
+	start := time.Date(2025, 10, 20, 12, 0, 0, 0, time.UTC)
+	want := []time.Time{start, start.Add(500 * time.Millisecond), start.Add(time.Second)}
+	if !slices.Equal(when, want) {
+		t.Errorf("время кадров %v, want %v", when, want)
+	}
+}
+
+func TestDownmix(t *testing.T) {
+	for _, tc := range []struct {
+		in       []int16
+		channels int
+		want     []int16
+	}{
+		{[]int16{1, -2, 3}, 1, []int16{1, -2, 3}},
+		{[]int16{100, 200, -100, -201, 32767, 32767, -32768, -32768}, 2, []int16{150, -150, 32767, -32768}},
+		{[]int16{1, 2, 3}, 2, []int16{1}}, // неполный блок отбрасывается
+	} {
+		out := downmix(samples16(tc.in...), tc.channels)
+		var got []int16
+		for i := 0; i+1 < len(out); i += 2 {
+			got = append(got, int16(binary.LittleEndian.Uint16(out[i:])))
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("downmix(%v, %d) = %v, want %v", tc.in, tc.channels, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// C:\_Projects_Go\AcousticLog\internal\audio\winmm\source_other.go
+
+//go:build !windows
+
+package winmm
+
+import (
+	"context"
+	"errors"
+
+	"acousticlog/internal/audio"
+)
+
+// ErrUnsupported — WinMM есть только в Windows.
+var ErrUnsupported = errors.New("winmm: capture is only available on Windows")
+
+// Source — заглушка для не-Windows сборок: Open всегда возвращает ErrUnsupported.
+type Source struct {
+	sampleRate int
+}
+
+var _ audio.Source = (*Source)(nil)
+
+func NewSource(sampleRate, bufferMs int) *Source { return &Source{sampleRate: sampleRate} }
+
+func (s *Source) Format() audio.Format { return audio.Mono16(s.sampleRate) }
+func (s *Source) Open() error          { return ErrUnsupported }
+func (s *Source) Close() error         { return nil }
+
+func (s *Source) Read(ctx context.Context) (audio.Frame, error) {
+	return audio.Frame{}, ErrUnsupported
+}
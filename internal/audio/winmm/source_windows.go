@@ -0,0 +1,134 @@
+// C:\_Projects_Go\AcousticLog\internal\audio\winmm\source_windows.go
+
+//go:build windows
+
+package winmm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sys/windows"
+
+	"acousticlog/internal/audio"
+)
+
+// waitSlice — сколько ждём событие драйвера за раз: между ожиданиями проверяется ctx,
+// так что остановка не ждёт следующего буфера дольше этого.
+const waitSlice = 100 * time.Millisecond
+
+// Source — захват с устройства WinMM (WAVE_MAPPER) через кольцо из нескольких буферов.
+type Source struct {
+	sampleRate int
+	bufferMs   int
+	nbufs      int
+
+	handle uintptr
+	event  windows.Handle // взводится драйвером на каждом заполненном буфере (CALLBACK_EVENT)
+	fmtx   WAVEFORMATEX
+	bufs   []*Buffer
+	next   int // WinMM заполняет буферы по очереди — читаем в том же порядке
+	open   bool
+}
+
+var _ audio.Source = (*Source)(nil)
+
+// NewSource — источник WinMM моно 16 бит с буферами по bufferMs миллисекунд.
+func NewSource(sampleRate, bufferMs int) *Source {
+	return &Source{sampleRate: sampleRate, bufferMs: bufferMs, nbufs: 3}
+}
+
+func (s *Source) Format() audio.Format { return audio.Mono16(s.sampleRate) }
+
+func (s *Source) Open() error {
+	s.fmtx = WaveFormatPCM1ch16(s.sampleRate)
+	// автосброс: одно ожидание — одна отметка драйвера; флаги буферов всё равно проверяются до ожидания
+	ev, err := windows.CreateEvent(nil, 0, 0, nil)
+	if err != nil {
+		return fmt.Errorf("CreateEvent: %w", err)
+	}
+	h, err := WaveInOpen(WAVE_MAPPER, &s.fmtx, ev)
+	if err != nil {
+		_ = windows.CloseHandle(ev)
+		return fmt.Errorf("waveInOpen: %w", err)
+	}
+	s.handle, s.event = h, ev
+
+	size := audio.BufferBytes(s.Format(), s.bufferMs)
+	s.bufs = make([]*Buffer, s.nbufs)
+	for i := range s.bufs {
+		mem := make([]byte, size)
+		s.bufs[i] = &Buffer{Mem: mem, Hdr: WAVEHDR{LpData: &mem[0], DwBufferLength: uint32(len(mem))}}
+	}
+	for _, b := range s.bufs {
+		if err := WaveInPrepareHeader(s.handle, &b.Hdr); err != nil {
+			s.release()
+			return fmt.Errorf("prepare: %w", err)
+		}
+		if err := WaveInAddBuffer(s.handle, &b.Hdr); err != nil {
+			s.release()
+			return fmt.Errorf("addbuf: %w", err)
+		}
+	}
+	if err := WaveInStart(s.handle); err != nil {
+		s.release()
+		return fmt.Errorf("start: %w", err)
+	}
+	s.next = 0
+	s.open = true
+	return nil
+}
+
+func (s *Source) Read(ctx context.Context) (audio.Frame, error) {
+	if !s.open {
+		return audio.Frame{}, audio.ErrNotOpen
+	}
+	for {
+		b := s.bufs[s.next]
+		if (b.Hdr.DwFlags & WHDR_DONE) != 0 {
+			n := int(b.Hdr.DwBytesRecorded)
+			if n > len(b.Mem) {
+				n = len(b.Mem)
+			}
+			// буфер готов — значит, он только что кончился: начало на его длительность раньше
+			when := time.Now().Add(-s.Format().Duration(n))
+			pcm := append([]byte(nil), b.Mem[:n]...)
+			b.Hdr.DwFlags &^= WHDR_DONE
+			b.Hdr.DwBytesRecorded = 0
+			if err := WaveInAddBuffer(s.handle, &b.Hdr); err != nil {
+				return audio.Frame{}, fmt.Errorf("addbuf: %w", err)
+			}
+			s.next = (s.next + 1) % len(s.bufs)
+			return audio.Frame{PCM: pcm, When: when}, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return audio.Frame{}, err
+		}
+		// буфер не готов — ждём отметку драйвера (таймаут — только чтобы проверить ctx)
+		if _, err := windows.WaitForSingleObject(s.event, uint32(waitSlice/time.Millisecond)); err != nil {
+			return audio.Frame{}, fmt.Errorf("WaitForSingleObject: %w", err)
+		}
+	}
+}
+
+func (s *Source) Close() error {
+	if !s.open {
+		return nil
+	}
+	s.open = false
+	return s.release()
+}
+
+// release — останов устройства и освобождение заголовков (ошибки кроме закрытия игнорируем).
+func (s *Source) release() error {
+	_ = WaveInStop(s.handle)
+	for _, b := range s.bufs {
+		_ = WaveInUnprepareHeader(s.handle, &b.Hdr)
+	}
+	err := WaveInClose(s.handle)
+	_ = windows.CloseHandle(s.event)
+	s.handle, s.event = 0, 0
+	s.bufs = nil
+	return err
+}
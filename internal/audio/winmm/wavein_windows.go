@@ -15,6 +15,7 @@ const (
 	WAVE_MAPPER      = 0xFFFFFFFF
 	MMSYSERR_NOERROR = 0
 	CALLBACK_NULL    = 0
+	CALLBACK_EVENT   = 0x00050000
 	WHDR_DONE        = 0x00000001
 )
 
@@ -29,10 +30,16 @@ var (
 	procWaveInStop      = winmm.NewProc("waveInStop")
 )
 
-func WaveInOpen(deviceID uint32, pwfx *WAVEFORMATEX) (uintptr, error) {
+// WaveInOpen — открыть устройство; event != 0 — событие, которое драйвер взводит на
+// каждом заполненном буфере (CALLBACK_EVENT), иначе готовность только по WHDR_DONE.
+func WaveInOpen(deviceID uint32, pwfx *WAVEFORMATEX, event windows.Handle) (uintptr, error) {
 	var h uintptr
+	flags := uintptr(CALLBACK_NULL)
+	if event != 0 {
+		flags = CALLBACK_EVENT
+	}
 	r0, _, _ := procWaveInOpen.Call(uintptr(unsafe.Pointer(&h)), uintptr(deviceID),
-		uintptr(unsafe.Pointer(pwfx)), 0, 0, uintptr(CALLBACK_NULL))
+		uintptr(unsafe.Pointer(pwfx)), uintptr(event), 0, flags)
 	if r0 != MMSYSERR_NOERROR {
 		return 0, fmt.Errorf("waveInOpen failed: %d", r0)
 	}
@@ -4,16 +4,291 @@ package io
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 )
 
-var DefaultCSVHeader = []string{"Timestamp", "Mode", "dBFS", "dB_SPL", "Limit", "Status", "WAV_File"}
+var DefaultCSVHeader = CSVHeader("Z")
 
-func CreateCSV(dir, prefix string, delim rune, header []string) (*os.File, *csv.Writer, string, error) {
-	name := fmt.Sprintf("%s_%s.csv", prefix, time.Now().Format("20060102_150405"))
-	path := dir + string(os.PathSeparator) + name
+// Качество измерения — колонка Quality в sound_log/sound_all: можно ли доверять уровням строки.
+const (
+	QualityOK          = "OK"
+	QualityClipped     = "CLIPPED"      // вход перегружен: уровень занижен, звук искажён
+	QualityGap         = "GAP"          // в интервале потерян звук
+	QualityDeviceError = "DEVICE_ERROR" // устройство потеряно (сторож захвата)
+	QualityShutdown    = "SHUTDOWN"     // интервал оборван остановкой программы
+)
+
+// qualityRank — от лучшего к худшему; пустое качество — OK.
+var qualityRank = []string{QualityOK, QualityShutdown, QualityClipped, QualityGap, QualityDeviceError}
+
+// WorseQuality — худшее из двух качеств (для события, собранного из многих буферов).
+func WorseQuality(a, b string) string {
+	rank := func(q string) int {
+		for i, v := range qualityRank {
+			if v == q {
+				return i
+			}
+		}
+		return 0
+	}
+	if rank(b) > rank(a) {
+		return b
+	}
+	return a
+}
+
+func quality(q string) string {
+	if q == "" {
+		return QualityOK
+	}
+	return q
+}
+
+// CSVHeader — заголовок журнала sound_all; частотная коррекция попадает
+// в имена колонок уровня: dB_SPL(A), LAF, LAFmax, LAS, LASmax, LAI, LAImax.
+func CSVHeader(weighting string) []string {
+	l := "L" + weighting
+	return []string{"Timestamp", "Mode", "dBFS", fmt.Sprintf("dB_SPL(%s)", weighting),
+		l + "F", l + "Fmax", l + "S", l + "Smax", l + "I", l + "Imax",
+		"Limit", "Status", "WAV_File", "Quality"}
+}
+
+// Row — строка журнала sound_all в порядке CSVHeader.
+type Row struct {
+	Time  time.Time
+	Mode  string
+	DBFS  float64
+	DBSPL float64 // энергетическое среднее буфера с коррекцией
+
+	// временные характеристики F/S/I: на конце буфера и максимум в нём
+	LF, LFMax float64
+	LS, LSMax float64
+	LI, LIMax float64
+
+	Limit   float64
+	Status  string
+	WAV     string
+	Quality string // "" — OK
+}
+
+// Record — строка CSV; NaN (уровень не определён, буфер цифровой тишины) — пустая ячейка.
+func (r Row) Record() []string {
+	f2 := func(v float64) string {
+		if math.IsNaN(v) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return []string{r.Time.Format("2006-01-02 15:04:05.000"), r.Mode,
+		f2(r.DBFS), f2(r.DBSPL),
+		f2(r.LF), f2(r.LFMax), f2(r.LS), f2(r.LSMax), f2(r.LI), f2(r.LIMax),
+		strconv.FormatFloat(r.Limit, 'f', 1, 64), r.Status, r.WAV, quality(r.Quality)}
+}
+
+// EventCSVHeader — заголовок sound_log: одна строка на событие (непрерывное превышение
+// с учётом -event-hang), например LAeq, LAFmax и пик сэмпла без коррекции LZpeak;
+// фон L90 на начало события, наибольший пик-фактор и параметры детектора импульсов.
+func EventCSVHeader(weighting, timeWeighting string) []string {
+	l := "L" + weighting
+	return []string{"Start", "End", "Duration_s", "Kind", "Mode", "Limit",
+		l + "eq", l + timeWeighting + "max", "LZpeak", "Baseline_" + l + "90", "Crest_dB", "Detector", "WAV_File", "Quality"}
+}
+
+// EventRow — строка sound_log за одно событие.
+type EventRow struct {
+	Start, End time.Time
+	Kind       string
+	Mode       string
+	Limit      float64
+	Leq, Max   float64
+	Peak       float64
+	Baseline   float64 // NaN — фон ещё не накоплен
+	Crest      float64
+	Detector   string
+	WAV        string
+	Quality    string // "" — OK
+}
+
+func (r EventRow) Record() []string {
+	f2 := func(v float64) string {
+		if math.IsNaN(v) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return []string{r.Start.Format("2006-01-02 15:04:05.000"), r.End.Format("2006-01-02 15:04:05.000"),
+		strconv.FormatFloat(r.End.Sub(r.Start).Seconds(), 'f', 1, 64), r.Kind, r.Mode,
+		strconv.FormatFloat(r.Limit, 'f', 1, 64), f2(r.Leq), f2(r.Max), f2(r.Peak),
+		f2(r.Baseline), f2(r.Crest), r.Detector, r.WAV, quality(r.Quality)}
+}
+
+// StatsCSVHeader — заголовок sound_stats: Leq, максимум/минимум по временной
+// характеристике и процентили, например LAeq, LAFmax, LAFmin, LA10, LA50, LA90.
+func StatsCSVHeader(weighting, timeWeighting string) []string {
+	l := "L" + weighting
+	return []string{"Start", "End", "Interval", l + "eq", l + timeWeighting + "max", l + timeWeighting + "min",
+		l + "10", l + "50", l + "90", "Measured_s"}
+}
+
+// StatsRow — строка sound_stats за один интервал.
+type StatsRow struct {
+	Start, End    time.Time
+	Interval      string // 1m, 15m, 1h…
+	Leq, Max, Min float64
+	L10, L50, L90 float64
+	Measured      float64 // секунд с данными (меньше длины интервала — были пропуски)
+}
+
+func (r StatsRow) Record() []string {
+	f2 := func(v float64) string { return strconv.FormatFloat(v, 'f', 2, 64) }
+	return []string{r.Start.Format("2006-01-02 15:04:05"), r.End.Format("2006-01-02 15:04:05"), r.Interval,
+		f2(r.Leq), f2(r.Max), f2(r.Min), f2(r.L10), f2(r.L50), f2(r.L90),
+		strconv.FormatFloat(r.Measured, 'f', 1, 64)}
+}
+
+// DailyCSVHeader — заголовок sound_daily: уровни за дневной, вечерний и ночной периоды,
+// Lden/Ldn со штрафами (+5 вечер, +10 ночь) и измеренное время по периодам.
+func DailyCSVHeader(weighting string) []string {
+	l := "L" + weighting
+	return []string{"Date", l + "day", l + "evening", l + "night", "Lden", "Ldn",
+		"Day_s", "Evening_s", "Night_s"}
+}
+
+// DailyRow — строка sound_daily за сутки. NaN — показатель не определён
+// (период не измерялся) и пишется пустой ячейкой.
+type DailyRow struct {
+	Date                   string
+	Day, Evening, Night    float64
+	Lden, Ldn              float64
+	DayS, EveningS, NightS float64
+}
+
+func (r DailyRow) Record() []string {
+	f2 := func(v float64) string {
+		if math.IsNaN(v) {
+			return ""
+		}
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	f1 := func(v float64) string { return strconv.FormatFloat(v, 'f', 1, 64) }
+	return []string{r.Date, f2(r.Day), f2(r.Evening), f2(r.Night), f2(r.Lden), f2(r.Ldn),
+		f1(r.DayS), f1(r.EveningS), f1(r.NightS)}
+}
+
+// ReadDailyRow — строка за date из sound_daily path, записанная прошлой сессией.
+// Нет файла или строки — ok=false без ошибки.
+func ReadDailyRow(path string, delim rune, date string) (row DailyRow, ok bool, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return row, false, nil
+	}
+	if err != nil {
+		return row, false, err
+	}
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(string(data), "\ufeff")))
+	r.Comma = delim
+	r.FieldsPerRecord = -1
+	recs, err := r.ReadAll()
+	if err != nil {
+		return row, false, fmt.Errorf("%s: %w", path, err)
+	}
+	for _, rec := range recs[min(1, len(recs)):] { // первая строка — заголовок
+		if len(rec) < 9 || rec[0] != date {
+			continue
+		}
+		var v [8]float64
+		for i, s := range rec[1:9] {
+			if s == "" {
+				v[i] = math.NaN()
+				continue
+			}
+			if v[i], err = strconv.ParseFloat(s, 64); err != nil {
+				return row, false, fmt.Errorf("%s: некорректная строка %q", path, strings.Join(rec, string(delim)))
+			}
+		}
+		return DailyRow{Date: date, Day: v[0], Evening: v[1], Night: v[2], Lden: v[3], Ldn: v[4],
+			DayS: v[5], EveningS: v[6], NightS: v[7]}, true, nil
+	}
+	return row, false, nil
+}
+
+// RewriteCSV — CSV целиком (BOM, заголовок, строки) через временный файл: при сбое
+// остаётся прежняя версия, а не обрывок.
+func RewriteCSV(path string, delim rune, header []string, recs ...[]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("mkdir csv: %w", err)
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write([]byte{0xEF, 0xBB, 0xBF})
+	w := csv.NewWriter(f)
+	w.Comma = delim
+	w.UseCRLF = true
+	if err == nil {
+		_ = w.Write(header)
+		_ = w.WriteAll(recs)
+		err = w.Error()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// BandCSVHeader — заголовок sound_bands: уровни в полосах (без коррекции, Z) по
+// номинальным частотам, например L31.5Hz … L8000Hz.
+func BandCSVHeader(labels []string) []string {
+	h := []string{"Timestamp", "Kind", "Duration_s"}
+	for _, l := range labels {
+		h = append(h, "L"+l+"Hz")
+	}
+	return append(h, "WAV_File")
+}
+
+// BandRow — строка sound_bands: интервал (Kind=INTERVAL) или буфер события
+// (EXCEEDED/IMPULSE, со ссылкой на клип). NaN — полоса выше Найквиста, пустая ячейка.
+type BandRow struct {
+	Time     time.Time
+	Kind     string
+	Duration float64
+	Levels   []float64
+	WAV      string
+}
+
+func (r BandRow) Record() []string {
+	rec := []string{r.Time.Format("2006-01-02 15:04:05.000"), r.Kind, strconv.FormatFloat(r.Duration, 'f', 1, 64)}
+	for _, v := range r.Levels {
+		if math.IsNaN(v) {
+			rec = append(rec, "")
+			continue
+		}
+		rec = append(rec, strconv.FormatFloat(v, 'f', 2, 64))
+	}
+	return append(rec, r.WAV)
+}
+
+// CreateCSV — создаёт CSV-журнал prefix за дату по шаблону Layout.CSV (с BOM и заголовком);
+// created — момент создания по шкале сессии ({created}, {hour}), а не по часам системы:
+// у повтора файла и генератора он в прошлом.
+func CreateCSV(l Layout, date, prefix string, created time.Time, delim rune, header []string) (*os.File, *csv.Writer, string, error) {
+	path := l.CSVPath(date, prefix, created)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, nil, "", fmt.Errorf("mkdir csv: %w", err)
+	}
 	f, err := os.Create(path)
 	if err != nil {
 		return nil, nil, "", err
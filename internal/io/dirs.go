@@ -3,29 +3,132 @@
 package io
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"time"
 )
 
-func EnsureOutDir() (root, csvDir, wavDir string, err error) {
-	return EnsureOutDirForDate(time.Now().Format("2006-01-02"))
+// Шаблоны путей по умолчанию — прежняя раскладка DataSound_Temp\YYYY-MM-DD\{CSV,WAV}.
+// Разделитель в шаблонах всегда "/", на Windows он заменяется на "\".
+//
+// Подстановки:
+//
+//	{root}    — корень (-out-root)
+//	{date}    — YYYY-MM-DD
+//	{hour}    — HH
+//	{kind}    — EXCEEDED | IMPULSE | …
+//	{ts}      — YYYYMMDD_HHMMSS.mmm (момент буфера, только для WAV)
+//	{prefix}  — sound_log | sound_all | … (только для CSV)
+//	{created} — YYYYMMDD_HHMMSS создания файла (только для CSV)
+const (
+	DefaultWAVTemplate    = "{root}/{date}/WAV/{hour}/{kind}/noise_{ts}.wav"
+	DefaultCSVTemplate    = "{root}/{date}/CSV/{prefix}_{created}.csv"
+	DefaultMergedTemplate = "{root}/{date}/WAV/_Merged_Exceeded/merged_exceeded_{date}_{hour}.wav"
+)
+
+// Layout — где лежат CSV, WAV-клипы и часовые склейки.
+type Layout struct {
+	Root   string
+	WAV    string
+	CSV    string
+	Merged string
 }
 
-func EnsureOutDirForDate(dateStr string) (root, csvDir, wavDir string, err error) {
-	base := `C:\DataSound_Temp`
-	if st, e := os.Stat(`D:\`); e == nil && st.IsDir() {
-		base = `D:\DataSound_Temp`
+// DefaultOutRoot — C:\DataSound_Temp (или D:\, если диск D: есть) на Windows,
+// ~/DataSound_Temp на остальных системах.
+func DefaultOutRoot() string {
+	if runtime.GOOS == "windows" {
+		if st, e := os.Stat(`D:\`); e == nil && st.IsDir() {
+			return `D:\DataSound_Temp`
+		}
+		return `C:\DataSound_Temp`
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(home, "DataSound_Temp")
+	}
+	return "DataSound_Temp"
+}
+
+// Validate — {ts} и {kind} обязательны в WAV (уникальность клипов; без {kind} маски
+// разных видов совпадут и склейка возьмёт клип дважды), {prefix} — в CSV,
+// {hour} — в склейке (иначе часы одного дня перезапишут друг друга).
+func (l Layout) Validate() error {
+	if l.Root == "" {
+		return errors.New("layout: empty root")
+	}
+	if !strings.Contains(l.WAV, "{ts}") {
+		return fmt.Errorf("layout: WAV template %q must contain {ts}", l.WAV)
+	}
+	if !strings.Contains(l.WAV, "{kind}") {
+		return fmt.Errorf("layout: WAV template %q must contain {kind}", l.WAV)
+	}
+	if !strings.Contains(l.CSV, "{prefix}") {
+		return fmt.Errorf("layout: CSV template %q must contain {prefix}", l.CSV)
+	}
+	if !strings.Contains(l.Merged, "{hour}") {
+		return fmt.Errorf("layout: merged template %q must contain {hour}", l.Merged)
+	}
+	return nil
+}
+
+func (l Layout) expand(tmpl string, kv ...string) string {
+	r := strings.NewReplacer(append([]string{"{root}", filepath.ToSlash(l.Root)}, kv...)...)
+	return filepath.FromSlash(r.Replace(tmpl))
+}
+
+// WAVPath — путь клипа события kind для буфера, начавшегося в t.
+func (l Layout) WAVPath(t time.Time, kind string) string {
+	return l.expand(l.WAV,
+		"{date}", t.Format("2006-01-02"), "{hour}", t.Format("15"),
+		"{kind}", normalizeEventKind(kind), "{ts}", t.Format("20060102_150405.000"))
+}
+
+// ClipGlob — маска всех клипов kind за дату и час (для склейки/подсчёта).
+// {ts} начинается с YYYYMMDD_HH, поэтому час отбирается даже без {hour} в шаблоне.
+func (l Layout) ClipGlob(date, hour, kind string) string {
+	esc := Layout{Root: globEscape(l.Root)}
+	ts := globEscape(strings.ReplaceAll(date, "-", "")+"_"+hour) + "*"
+	return esc.expand(l.WAV,
+		"{date}", globEscape(date), "{hour}", globEscape(hour),
+		"{kind}", globEscape(normalizeEventKind(kind)), "{ts}", ts)
+}
+
+// CSVPath — путь CSV-журнала prefix за дату, созданного в момент created.
+func (l Layout) CSVPath(date, prefix string, created time.Time) string {
+	return l.expand(l.CSV,
+		"{date}", date, "{hour}", created.Format("15"),
+		"{prefix}", prefix, "{created}", created.Format("20060102_150405"))
+}
+
+// MergedPath — путь часовой склейки.
+func (l Layout) MergedPath(date, hour string) string {
+	return l.expand(l.Merged, "{date}", date, "{hour}", hour)
+}
+
+// EnsureForDate — создаёт корень и каталог CSV на дату (created — как в CreateCSV);
+// каталоги WAV создаются при записи.
+func (l Layout) EnsureForDate(dateStr string, created time.Time) (root, csvDir string, err error) {
+	root = l.Root
+	csvDir = filepath.Dir(l.CSVPath(dateStr, "sound_log", created))
+	if err = os.MkdirAll(root, 0o755); err != nil {
+		return "", "", fmt.Errorf("mkdir %s: %w", root, err)
 	}
-	root = filepath.Join(base, dateStr)
-	csvDir = filepath.Join(root, "CSV")
-	wavDir = filepath.Join(root, "WAV")
 	if err = os.MkdirAll(csvDir, 0o755); err != nil {
-		return "", "", "", fmt.Errorf("mkdir %s: %w", csvDir, err)
+		return "", "", fmt.Errorf("mkdir %s: %w", csvDir, err)
 	}
-	if err = os.MkdirAll(wavDir, 0o755); err != nil {
-		return "", "", "", fmt.Errorf("mkdir %s: %w", wavDir, err)
+	return root, csvDir, nil
+}
+
+// globEscape — экранирует метасимволы filepath.Match в подставляемом значении.
+func globEscape(s string) string {
+	if runtime.GOOS == "windows" {
+		// на Windows "\" — разделитель, экранирования нет; метасимволы в датах/часах не встречаются
+		return s
 	}
-	return
+	r := strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`, `\`, `\\`)
+	return r.Replace(s)
 }
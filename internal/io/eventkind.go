@@ -1,16 +1,24 @@
 // C:\_Projects_Go\AcousticLog\internal\io\eventkind.go
 package io
 
+import "strings"
+
 const (
 	EventKindExceeded = "EXCEEDED" // длительное превышение порога
 	EventKindImpulse  = "IMPULSE"  // импульсный пик
+	EventKindBand     = "BAND"     // превышение порога в октавной (третьоктавной) полосе
+	EventKindLowFreq  = "LOWFREQ"  // низкочастотный шум: бас, сабвуфер
 )
 
+// normalizeEventKind — вид для путей: встроенные и имена правил -rules (A-Z, 0-9, _)
+// как есть, остальное — EXCEEDED.
 func normalizeEventKind(kind string) string {
 	switch kind {
-	case EventKindImpulse:
-		return EventKindImpulse
-	default:
+	case EventKindImpulse, EventKindBand, EventKindLowFreq:
+		return kind
+	}
+	if kind == "" || strings.TrimLeft(kind, "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_") != "" {
 		return EventKindExceeded
 	}
+	return kind
 }
@@ -0,0 +1,83 @@
+// C:\_Projects_Go\AcousticLog\internal\io\floor.go
+
+package io
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// NoiseFloorFile — фон по часам суток в корне (общий для всех дат, переживает перезапуск).
+const NoiseFloorFile = "noise_floor.csv"
+
+// LoadNoiseFloor — фон по часам 00…23 (NaN — час ещё не изучен). Нет файла
+// или он испорчен — всё NaN (с ошибкой во втором случае).
+func LoadNoiseFloor(root string) ([24]float64, error) {
+	var empty, out [24]float64
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	empty = out
+	f, err := os.Open(filepath.Join(root, NoiseFloorFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return out, nil
+	}
+	if err != nil {
+		return empty, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.Comma = ';'
+	r.FieldsPerRecord = -1
+	recs, err := r.ReadAll()
+	if err != nil {
+		return empty, fmt.Errorf("%s: %w", NoiseFloorFile, err)
+	}
+	for _, rec := range recs[min(1, len(recs)):] { // первая строка — заголовок
+		if len(rec) < 2 || rec[1] == "" {
+			continue
+		}
+		h, err1 := strconv.Atoi(strings.TrimSpace(rec[0]))
+		v, err2 := strconv.ParseFloat(strings.TrimSpace(rec[1]), 64)
+		if err1 != nil || err2 != nil || h < 0 || h > 23 {
+			return empty, fmt.Errorf("%s: некорректная строка %q", NoiseFloorFile, strings.Join(rec, ";"))
+		}
+		out[h] = v
+	}
+	return out, nil
+}
+
+// SaveNoiseFloor — перезапись файла фона (через временный файл, чтобы не оставить обрывок).
+func SaveNoiseFloor(root string, floor [24]float64) error {
+	path := filepath.Join(root, NoiseFloorFile)
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	w.Comma = ';'
+	_ = w.Write([]string{"Hour", "L90"})
+	for h, v := range floor {
+		s := ""
+		if !math.IsNaN(v) {
+			s = strconv.FormatFloat(v, 'f', 2, 64)
+		}
+		_ = w.Write([]string{fmt.Sprintf("%02d", h), s})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -13,9 +13,10 @@ import (
 )
 
 type MergeOptions struct {
-	OutDir   string
-	OutName  string // .wav
+	OutDir   string // пусто — каталог Layout.MergedPath
+	OutName  string // .wav; пусто — имя из Layout.MergedPath
 	LockName string
+	Kinds    []string // виды событий для склейки; пусто — только EXCEEDED
 }
 
 var (
@@ -23,29 +24,48 @@ var (
 	ErrFmtMismatch = errors.New("wav format mismatch between clips")
 )
 
-func FindExceededClips(hourDir string) ([]string, error) {
-	src := filepath.Join(hourDir, "EXCEEDED")
-	entries, err := os.ReadDir(src)
+// FindClips — клипы события kind за дату и час по шаблону Layout.WAV, в порядке времени.
+func FindClips(l Layout, date, hour, kind string) ([]string, error) {
+	matches, err := filepath.Glob(l.ClipGlob(date, hour, kind))
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
 	var files []string
-	for _, e := range entries {
-		if e.IsDir() {
+	for _, m := range matches {
+		if !strings.HasSuffix(strings.ToLower(m), ".wav") {
 			continue
 		}
-		low := strings.ToLower(e.Name())
-		if strings.HasSuffix(low, ".wav") && strings.HasPrefix(low, "noise_") {
-			files = append(files, filepath.Join(src, e.Name()))
+		if st, err := os.Stat(m); err == nil && !st.IsDir() {
+			files = append(files, m)
 		}
 	}
 	sort.Strings(files)
 	return files, nil
 }
 
+// FindExceededClips — клипы EXCEEDED за дату и час.
+func FindExceededClips(l Layout, date, hour string) ([]string, error) {
+	return FindClips(l, date, hour, EventKindExceeded)
+}
+
+// FindEventClips — клипы нескольких видов событий за дату и час, в порядке времени
+// (по имени файла с отметкой времени); пустой kinds — только EXCEEDED.
+func FindEventClips(l Layout, date, hour string, kinds []string) ([]string, error) {
+	if len(kinds) == 0 {
+		return FindExceededClips(l, date, hour)
+	}
+	var files []string
+	for _, k := range kinds {
+		found, err := FindClips(l, date, hour, k)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	sort.SliceStable(files, func(i, j int) bool { return filepath.Base(files[i]) < filepath.Base(files[j]) })
+	return files, nil
+}
+
 type wavInfo struct {
 	fmtChunk []byte
 	dataSize uint32
@@ -149,10 +169,14 @@ func writeWAV(out *os.File, fmtChunk []byte, totalData uint32, concat func(w io.
 	return nil
 }
 
-func MergeHour(ctx context.Context, dayWavDir, hour string, opts MergeOptions) (string, error) {
-	outDir := opts.OutDir
-	if outDir == "" {
-		outDir = filepath.Join(dayWavDir, "_Merged_Exceeded")
+// MergeHour — склеивает клипы EXCEEDED (и видов opts.Kinds) за дату и час в один WAV (Layout.MergedPath).
+func MergeHour(ctx context.Context, l Layout, date, hour string, opts MergeOptions) (string, error) {
+	outDir, outName := filepath.Split(l.MergedPath(date, hour))
+	if opts.OutDir != "" {
+		outDir = opts.OutDir
+	}
+	if opts.OutName != "" {
+		outName = opts.OutName
 	}
 	if err := os.MkdirAll(outDir, 0755); err != nil {
 		return "", err
@@ -165,16 +189,22 @@ func MergeHour(ctx context.Context, dayWavDir, hour string, opts MergeOptions) (
 	_ = os.WriteFile(lock, []byte("1"), 0644)
 	defer os.Remove(lock)
 
-	hourDir := filepath.Join(dayWavDir, hour)
-	clips, err := FindExceededClips(hourDir)
+	outWav := filepath.Join(outDir, outName)
+	found, err := FindEventClips(l, date, hour, opts.Kinds)
 	if err != nil {
 		return "", err
 	}
+	// шаблоны могут положить склейку рядом с клипами — её саму не склеиваем
+	var clips []string
+	for _, c := range found {
+		if c != outWav {
+			clips = append(clips, c)
+		}
+	}
 	if len(clips) == 0 {
 		return "", ErrNoClips
 	}
 
-	outWav := filepath.Join(outDir, opts.OutName)
 	if _, err := os.Stat(outWav); err == nil {
 		_ = os.Remove(outWav)
 	}
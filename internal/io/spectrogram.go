@@ -0,0 +1,284 @@
+// C:\_Projects_Go\AcousticLog\internal\io\spectrogram.go
+
+package io
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"acousticlog/internal/mathx"
+)
+
+// Параметры картинки спектрограммы.
+const (
+	specMaxCols  = 1200  // столбцов времени не больше (длинные записи прореживаются)
+	specMinWidth = 480   // короткие клипы растягиваются до этой ширины
+	specDBMin    = -100. // нижняя граница цветовой шкалы, дБFS
+	specDBMax    = 0.
+	specLeft     = 44 // поле под частоты
+	specRight    = 64 // цветовая шкала
+	specTop      = 16 // заголовок
+	specBottom   = 18 // метки времени
+)
+
+// SpecSegment — участок склейки: с сэмпла Offset запись идёт от момента Start.
+type SpecSegment struct {
+	Offset int
+	Start  time.Time
+}
+
+// SpectrogramPNG — спектрограмма клипа (моно 16 бит) рядом с WAV: то же имя, .png.
+func SpectrogramPNG(wavPath string, rate int, pcm []byte, start time.Time) (string, error) {
+	total := len(pcm) / 2
+	read := func(pos int, dst []float64) {
+		for i := range dst {
+			dst[i] = 0
+			if p := pos + i; p < total {
+				dst[i] = float64(int16(binary.LittleEndian.Uint16(pcm[2*p:]))) / 32768.0
+			}
+		}
+	}
+	return writeSpectrogram(wavPath, rate, total, read, []SpecSegment{{Start: start}})
+}
+
+// tsPattern — отметка времени {ts} в имени клипа (см. Layout.WAVPath).
+var tsPattern = regexp.MustCompile(`\d{8}_\d{6}\.\d{3}`)
+
+// SpectrogramMerged — спектрограмма склейки часа из клипов clips (в порядке склейки):
+// шкала времени идёт по отметкам в именах клипов, поэтому паузы между событиями видны
+// как скачки меток, а не как растянутая тишина.
+func SpectrogramMerged(mergedPath string, clips []string) (string, error) {
+	f, err := os.Open(mergedPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := readWAVInfo(f)
+	if err != nil {
+		return "", err
+	}
+	if len(info.fmtChunk) < 24 || binary.LittleEndian.Uint16(info.fmtChunk[10:12]) != 1 ||
+		binary.LittleEndian.Uint16(info.fmtChunk[22:24]) != 16 {
+		return "", fmt.Errorf("spectrogram: нужен моно PCM 16 бит")
+	}
+	rate := int(binary.LittleEndian.Uint32(info.fmtChunk[12:16]))
+
+	var segs []SpecSegment
+	offset := 0
+	for _, c := range clips {
+		if c == mergedPath {
+			continue
+		}
+		cf, err := os.Open(c)
+		if err != nil {
+			return "", err
+		}
+		ci, err := readWAVInfo(cf)
+		cf.Close()
+		if err != nil {
+			return "", err
+		}
+		if ts := tsPattern.FindString(filepath.Base(c)); ts != "" {
+			// метки только печатаются обратно — зона не важна, берём UTC
+			if t, err := time.Parse("20060102_150405.000", ts); err == nil {
+				segs = append(segs, SpecSegment{Offset: offset, Start: t})
+			}
+		}
+		offset += int(ci.dataSize) / 2
+	}
+
+	total := int(info.dataSize) / 2
+	buf := make([]byte, 0, 8192)
+	read := func(pos int, dst []float64) {
+		buf = buf[:0]
+		if pos < total {
+			n := min(len(dst), total-pos)
+			buf = buf[:2*n]
+			if _, err := f.ReadAt(buf, info.dataOff+int64(2*pos)); err != nil {
+				buf = buf[:0]
+			}
+		}
+		for i := range dst {
+			dst[i] = 0
+			if 2*i+1 < len(buf) {
+				dst[i] = float64(int16(binary.LittleEndian.Uint16(buf[2*i:]))) / 32768.0
+			}
+		}
+	}
+	return writeSpectrogram(mergedPath, rate, total, read, segs)
+}
+
+func writeSpectrogram(wavPath string, rate, total int, read func(int, []float64), segs []SpecSegment) (string, error) {
+	if total == 0 || rate <= 0 {
+		return "", fmt.Errorf("spectrogram: пустая запись")
+	}
+	n := mathx.SpectrogramWindow(rate)
+	cols := min(specMaxCols, max(1, total/(n/4)))
+	spec := mathx.Spectrogram(total, n, cols, read)
+
+	plotW := max(cols, specMinWidth)
+	plotH := n / 2
+	img := image.NewRGBA(image.Rect(0, 0, specLeft+plotW+specRight, specTop+plotH+specBottom))
+	fillRect(img, img.Bounds(), color.RGBA{16, 16, 16, 255})
+
+	for x := 0; x < plotW; x++ {
+		col := spec[x*cols/plotW]
+		for y := 0; y < plotH; y++ {
+			bin := (plotH - 1 - y) * (len(col) - 1) / (plotH - 1)
+			img.Set(specLeft+x, specTop+y, dbColor(col[bin]))
+		}
+	}
+
+	label := color.RGBA{220, 220, 220, 255}
+	grid := color.RGBA{90, 90, 90, 255}
+
+	// частоты: метки по октавам, если хватает места (шкала линейная)
+	nyq := float64(rate) / 2
+	lastY := specTop + plotH + 12
+	for f := 500.0; f < nyq; f *= 2 {
+		y := specTop + plotH - 1 - int(f/nyq*float64(plotH-1))
+		if y < specTop+6 {
+			break
+		}
+		if lastY-y < 14 {
+			continue
+		}
+		lastY = y
+		fillRect(img, image.Rect(specLeft-3, y, specLeft, y+1), grid)
+		s := fmt.Sprintf("%.0f", f)
+		if f >= 1000 {
+			s = fmt.Sprintf("%.0fk", f/1000)
+		}
+		drawText(img, specLeft-5-textWidth(s), y-5, s, label)
+	}
+	drawText(img, 2, specTop-12, "Hz", label)
+
+	// время: по сегментам (склейка) или от начала клипа
+	dur := float64(total) / float64(rate)
+	timeAt := func(sample int) (time.Time, bool) {
+		var seg *SpecSegment
+		for i := range segs {
+			if segs[i].Offset <= sample {
+				seg = &segs[i]
+			}
+		}
+		if seg == nil {
+			return time.Time{}, false
+		}
+		return seg.Start.Add(time.Duration(float64(sample-seg.Offset) / float64(rate) * float64(time.Second))), true
+	}
+	layout := "15:04:05"
+	if dur < 10 {
+		layout = "04:05.000"
+	}
+	for x := 0; x < plotW; x += 110 { // метка ~ раз в 110 px
+		sample := int(float64(x) / float64(plotW) * float64(total))
+		fillRect(img, image.Rect(specLeft+x, specTop+plotH, specLeft+x+1, specTop+plotH+3), grid)
+		if t, ok := timeAt(sample); ok {
+			drawText(img, specLeft+x+2, specTop+plotH+5, t.Format(layout), label)
+		}
+	}
+	if t, ok := timeAt(0); ok {
+		drawText(img, specLeft, 3, t.Format("2006-01-02 15:04:05.000"), label)
+	}
+	drawText(img, specLeft+plotW-textWidth(fmt.Sprintf("%.1fs", dur)), 3, fmt.Sprintf("%.1fs", dur), label)
+
+	// цветовая шкала дБFS
+	barX := specLeft + plotW + 8
+	for y := 0; y < plotH; y++ {
+		db := specDBMax - (specDBMax-specDBMin)*float64(y)/float64(plotH-1)
+		fillRect(img, image.Rect(barX, specTop+y, barX+10, specTop+y+1), dbColor(db))
+	}
+	for db := specDBMax; db >= specDBMin; db -= 20 {
+		y := specTop + int((specDBMax-db)/(specDBMax-specDBMin)*float64(plotH-1))
+		drawText(img, barX+13, y-3, fmt.Sprintf("%.0f", db), label)
+	}
+	drawText(img, barX, specTop-12, "dBFS", label)
+
+	pngPath := strings.TrimSuffix(wavPath, filepath.Ext(wavPath)) + ".png"
+	f, err := os.Create(pngPath)
+	if err != nil {
+		return "", fmt.Errorf("create png: %w", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return "", fmt.Errorf("encode png: %w", err)
+	}
+	return pngPath, f.Close()
+}
+
+// dbColor — палитра «чёрный → синий → пурпурный → красный → жёлтый → белый».
+func dbColor(db float64) color.RGBA {
+	stops := [...]color.RGBA{{0, 0, 0, 255}, {20, 20, 140, 255}, {150, 30, 160, 255}, {230, 50, 40, 255}, {250, 200, 30, 255}, {255, 255, 255, 255}}
+	t := (db - specDBMin) / (specDBMax - specDBMin)
+	t = math.Max(0, math.Min(1, t)) * float64(len(stops)-1)
+	i := min(int(t), len(stops)-2)
+	f := t - float64(i)
+	mix := func(a, b uint8) uint8 { return uint8(float64(a) + (float64(b)-float64(a))*f) }
+	a, b := stops[i], stops[i+1]
+	return color.RGBA{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B), 255}
+}
+
+func fillRect(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// Растровый шрифт 3×5 для подписей (только нужные символы), масштаб 2.
+var glyphs = map[rune][5]string{
+	'0': {"###", "#.#", "#.#", "#.#", "###"},
+	'1': {".#.", "##.", ".#.", ".#.", "###"},
+	'2': {"###", "..#", "###", "#..", "###"},
+	'3': {"###", "..#", "###", "..#", "###"},
+	'4': {"#.#", "#.#", "###", "..#", "..#"},
+	'5': {"###", "#..", "###", "..#", "###"},
+	'6': {"###", "#..", "###", "#.#", "###"},
+	'7': {"###", "..#", "..#", "..#", "..#"},
+	'8': {"###", "#.#", "###", "#.#", "###"},
+	'9': {"###", "#.#", "###", "..#", "###"},
+	':': {"...", ".#.", "...", ".#.", "..."},
+	'.': {"...", "...", "...", "...", ".#."},
+	'-': {"...", "...", "###", "...", "..."},
+	' ': {"...", "...", "...", "...", "..."},
+	'k': {"#..", "#.#", "##.", "#.#", "#.#"},
+	's': {"...", ".##", ".#.", "..#", "##."},
+	'H': {"#.#", "#.#", "###", "#.#", "#.#"},
+	'z': {"...", "###", ".#.", "#..", "###"},
+	'd': {"..#", "..#", "###", "#.#", "###"},
+	'B': {"##.", "#.#", "##.", "#.#", "##."},
+	'F': {"###", "#..", "##.", "#..", "#.."},
+	'S': {".##", "#..", ".#.", "..#", "##."},
+}
+
+const glyphScale = 2
+
+func textWidth(s string) int { return len([]rune(s)) * 4 * glyphScale }
+
+func drawText(img *image.RGBA, x, y int, s string, c color.RGBA) {
+	for _, r := range s {
+		g, ok := glyphs[r]
+		if ok {
+			for gy, row := range g {
+				for gx, px := range row {
+					if px == '#' {
+						fillRect(img, image.Rect(x+gx*glyphScale, y+gy*glyphScale, x+(gx+1)*glyphScale, y+(gy+1)*glyphScale), c)
+					}
+				}
+			}
+		}
+		x += 4 * glyphScale
+	}
+}
@@ -10,19 +10,13 @@ import (
 	"time"
 )
 
-// SaveWAV — обратная совместимость: пишет как EXCEEDED.
-func SaveWAV(wavRoot string, base time.Time, rate int, pcm []byte) (string, error) {
-	return SaveWAVKind(wavRoot, base, rate, pcm, EventKindExceeded)
-}
-
-// SaveWAVKind — сохраняет WAV в ...\WAV\<HH>\<Kind>\noise_YYYYMMDD_HHMMSS.mmm.wav
-func SaveWAVKind(wavRoot string, base time.Time, rate int, pcm []byte, kind string) (string, error) {
-	hourDir := filepath.Join(wavRoot, base.Format("15"), normalizeEventKind(kind))
-	if err := os.MkdirAll(hourDir, 0o755); err != nil {
+// SaveWAVKind — сохраняет WAV по шаблону Layout.WAV
+// (по умолчанию ...\WAV\<HH>\<Kind>\noise_YYYYMMDD_HHMMSS.mmm.wav).
+func SaveWAVKind(l Layout, base time.Time, rate int, pcm []byte, kind string) (string, error) {
+	path := l.WAVPath(base, kind)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return "", fmt.Errorf("mkdir hour/kind: %w", err)
 	}
-	filename := fmt.Sprintf("noise_%s.wav", base.Format("20060102_150405.000"))
-	path := filepath.Join(hourDir, filename)
 
 	f, err := os.Create(path)
 	if err != nil {
@@ -13,6 +13,23 @@ func BytesToInt16LE(b []byte) []int16 {
 	return out
 }
 
+// RMS — среднеквадратичное значение сэмплов в долях полной шкалы.
+func RMS(s []float64) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, x := range s {
+		sum += x * x
+	}
+	return math.Sqrt(sum / float64(len(s)))
+}
+
+// PowerToDB — 10*lg(ms) для среднего квадрата; 0 → -Inf.
+func PowerToDB(ms float64) float64 {
+	return 10 * math.Log10(ms)
+}
+
 func CalcRMSInt16(s []int16) float64 {
 	if len(s) == 0 {
 		return 0
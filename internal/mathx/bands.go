@@ -0,0 +1,188 @@
+// C:\_Projects_Go\AcousticLog\internal\mathx\bands.go
+
+package mathx
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// Bands — разрешение полосового анализа: октавы или трети октавы (IEC 61260), либо выключен.
+type Bands string
+
+const (
+	BandsOff    Bands = "off"
+	BandsOctave Bands = "octave"
+	BandsThird  Bands = "third"
+)
+
+func ParseBands(s string) (Bands, error) {
+	switch b := Bands(strings.ToLower(strings.TrimSpace(s))); b {
+	case "", BandsOff:
+		return BandsOff, nil
+	case BandsOctave, BandsThird:
+		return b, nil
+	default:
+		return "", fmt.Errorf("unknown band mode %q (octave|third|off)", s)
+	}
+}
+
+// Номинальные среднегеометрические частоты третьоктавных полос 31.5 Гц … 8 кГц;
+// каждая третья (31.5, 63, 125 …) — октавная. Точные частоты — 1000·2^(k/3).
+var nominalThirds = []float64{
+	31.5, 40, 50, 63, 80, 100, 125, 160, 200, 250, 315, 400, 500,
+	630, 800, 1000, 1250, 1600, 2000, 2500, 3150, 4000, 5000, 6300, 8000,
+}
+
+// biquad — IIR-секция второго порядка: y = b0*x + b1*x[-1] + b2*x[-2] - a1*y[-1] - a2*y[-2].
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (s *biquad) next(x float64) float64 {
+	y := s.b0*x + s.b1*s.x1 + s.b2*s.x2 - s.a1*s.y1 - s.a2*s.y2
+	s.x2, s.x1 = s.x1, x
+	s.y2, s.y1 = s.y1, y
+	return y
+}
+
+func (s *biquad) flush() {
+	flushTiny(&s.x1)
+	flushTiny(&s.x2)
+	flushTiny(&s.y1)
+	flushTiny(&s.y2)
+}
+
+func (s *biquad) response(z complex128) complex128 {
+	zi := 1 / z
+	num := complex(s.b0, 0) + complex(s.b1, 0)*zi + complex(s.b2, 0)*zi*zi
+	den := 1 + complex(s.a1, 0)*zi + complex(s.a2, 0)*zi*zi
+	return num / den
+}
+
+// band — полосовой фильтр Баттерворта 6-го порядка (три секции) для одной полосы.
+type band struct {
+	sections [3]biquad
+	ok       bool // верхняя граница ниже Найквиста
+}
+
+// bandPass — прототип Баттерворта 3-го порядка → полосовой (fl…fh) → билинейное
+// преобразование с предыскажением краёв. Усиление на средней частоте — 1.
+func bandPass(fl, fh, fs float64) [3]biquad {
+	wl := 2 * fs * math.Tan(math.Pi*fl/fs)
+	wh := 2 * fs * math.Tan(math.Pi*fh/fs)
+	w0sq := complex(wl*wh, 0)
+	bw := complex(wh-wl, 0)
+
+	var out [3]biquad
+	n := 0
+	for k := 0; k < 3; k++ {
+		p := cmplx.Exp(complex(0, math.Pi*float64(2*k+4)/6))
+		d := cmplx.Sqrt(p*bw*p*bw - 4*w0sq)
+		for _, s := range []complex128{(p*bw + d) / 2, (p*bw - d) / 2} {
+			// из каждой пары сопряжённых полюсов берём верхний — он задаёт секцию
+			if imag(s) <= 0 || n == len(out) {
+				continue
+			}
+			z := (complex(2*fs, 0) + s) / (complex(2*fs, 0) - s)
+			out[n] = biquad{b0: 1, b2: -1, a1: -2 * real(z), a2: real(z)*real(z) + imag(z)*imag(z)}
+			n++
+		}
+	}
+
+	f0 := math.Sqrt(fl * fh)
+	z0 := cmplx.Exp(complex(0, 2*math.Pi*f0/fs))
+	h := complex(1, 0)
+	for i := range out {
+		h *= out[i].response(z0)
+	}
+	g := math.Cbrt(1 / cmplx.Abs(h))
+	for i := range out {
+		out[i].b0 *= g
+		out[i].b2 *= g
+	}
+	return out
+}
+
+// BandFilterBank — набор полосовых фильтров 31.5 Гц … 8 кГц. Состояние сохраняется
+// между буферами. Полосы выше Найквиста (8 кГц при 16 кГц) не считаются — NaN.
+type BandFilterBank struct {
+	mode  Bands
+	bands []band
+}
+
+func NewBandFilterBank(mode Bands, sampleRate int) *BandFilterBank {
+	fs := float64(sampleRate)
+	half := 1.0 / 6 // половина ширины в октавах
+	step := 1
+	if mode == BandsOctave {
+		half, step = 0.5, 3
+	}
+	b := &BandFilterBank{mode: mode}
+	for i := 0; i < len(nominalThirds); i += step {
+		fc := 1000 * math.Pow(2, float64(i-15)/3)
+		fl, fh := fc*math.Pow(2, -half), fc*math.Pow(2, half)
+		bd := band{ok: fh < fs/2}
+		if bd.ok {
+			bd.sections = bandPass(fl, fh, fs)
+		}
+		b.bands = append(b.bands, bd)
+	}
+	return b
+}
+
+func (b *BandFilterBank) Mode() Bands { return b.mode }
+
+// BandLabels — номинальные частоты полос режима mode ("31.5", "63", … "8000");
+// от частоты дискретизации не зависят, поэтому годятся для заголовка CSV до открытия источника.
+func BandLabels(mode Bands) []string {
+	step := 1
+	if mode == BandsOctave {
+		step = 3
+	}
+	var out []string
+	for i := 0; i < len(nominalThirds); i += step {
+		out = append(out, strconv.FormatFloat(nominalThirds[i], 'f', -1, 64))
+	}
+	return out
+}
+
+// ProcessInt16 — средний квадрат сигнала в каждой полосе за буфер (доли полной шкалы);
+// NaN — полоса недоступна при этой частоте дискретизации.
+func (b *BandFilterBank) ProcessInt16(s []int16) []float64 {
+	out := make([]float64, len(b.bands))
+	for i := range b.bands {
+		bd := &b.bands[i]
+		if !bd.ok || len(s) == 0 {
+			out[i] = math.NaN()
+			continue
+		}
+		var sum float64
+		for _, v := range s {
+			y := float64(v) / 32768.0
+			for k := range bd.sections {
+				y = bd.sections[k].next(y)
+			}
+			sum += y * y
+		}
+		for k := range bd.sections {
+			bd.sections[k].flush()
+		}
+		out[i] = sum / float64(len(s))
+	}
+	return out
+}
+
+// Reset — сброс состояния (после разрыва потока).
+func (b *BandFilterBank) Reset() {
+	for i := range b.bands {
+		for k := range b.bands[i].sections {
+			s := &b.bands[i].sections[k]
+			s.x1, s.x2, s.y1, s.y2 = 0, 0, 0, 0
+		}
+	}
+}
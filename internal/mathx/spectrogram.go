@@ -0,0 +1,90 @@
+// C:\_Projects_Go\AcousticLog\internal\mathx\spectrogram.go
+
+package mathx
+
+import (
+	"math"
+	"math/bits"
+)
+
+// FFT — комплексное БПФ по месту (radix-2), длина — степень двойки.
+func FFT(re, im []float64) {
+	n := len(re)
+	shift := 64 - uint(bits.Len(uint(n))-1)
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse64(uint64(i)) >> shift)
+		if j > i {
+			re[i], re[j] = re[j], re[i]
+			im[i], im[j] = im[j], im[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := -2 * math.Pi / float64(size)
+		for start := 0; start < n; start += size {
+			for k := 0; k < size/2; k++ {
+				wr, wi := math.Cos(step*float64(k)), math.Sin(step*float64(k))
+				a, b := start+k, start+k+size/2
+				tr := wr*re[b] - wi*im[b]
+				ti := wr*im[b] + wi*re[b]
+				re[b], im[b] = re[a]-tr, im[a]-ti
+				re[a], im[a] = re[a]+tr, im[a]+ti
+			}
+		}
+	}
+}
+
+// SpectrogramWindow — длина окна БПФ для частоты дискретизации: ~30 мс, степень двойки
+// (512 при 16 кГц, 2048 при 48 кГц).
+func SpectrogramWindow(sampleRate int) int {
+	n := 256
+	for n < sampleRate/32 {
+		n <<= 1
+	}
+	return n
+}
+
+// Spectrogram — cols столбцов по n/2+1 бинов (0 … Найквист), уровни в дБ относительно
+// полной шкалы. Сигнал длиной total сэмплов читается через read(pos, dst) окнами
+// Ханна длины n; в столбец — средняя мощность не более 16 окон его участка, так что
+// длинные записи (склейка часа) считаются за ограниченное время.
+func Spectrogram(total, n, cols int, read func(pos int, dst []float64)) [][]float64 {
+	win := make([]float64, n)
+	var wsum float64
+	for i := range win {
+		win[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+		wsum += win[i]
+	}
+	// нормировка: синус полной шкалы даёт 0 дБ в своём бине
+	norm := 4 / (wsum * wsum)
+
+	last := max(0, total-n)
+	span := float64(last+1) / float64(cols)
+	re, im := make([]float64, n), make([]float64, n)
+	out := make([][]float64, cols)
+	for c := range out {
+		from := int(float64(c) * span)
+		to := max(from, int(float64(c+1)*span)-1)
+		k := min(16, max(1, (to-from)/(n/2)+1))
+		pow := make([]float64, n/2+1)
+		for w := 0; w < k; w++ {
+			pos := from
+			if k > 1 {
+				pos = from + (to-from)*w/(k-1)
+			}
+			read(pos, re)
+			for i := range re {
+				re[i] *= win[i]
+				im[i] = 0
+			}
+			FFT(re, im)
+			for i := range pow {
+				pow[i] += (re[i]*re[i] + im[i]*im[i]) * norm / float64(k)
+			}
+		}
+		for i, p := range pow {
+			pow[i] = 10 * math.Log10(p+1e-20)
+		}
+		out[c] = pow
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+// C:\_Projects_Go\AcousticLog\internal\mathx\stats.go
+
+package mathx
+
+import (
+	"math"
+	"sort"
+)
+
+// LevelStats — статистика уровней за интервал: Leq по энергии, максимум/минимум
+// и процентили Ln (уровень, превышаемый n% времени) по выборкам уровня.
+type LevelStats struct {
+	energy  float64 // Σ 10^(Leq/10)·dt
+	dur     float64 // секунд измерений
+	max     float64
+	min     float64
+	samples []float64
+}
+
+// Add — один буфер: его Leq и длительность dt (с), уровень для процентилей/минимума
+// (значение временной характеристики) и максимум внутри буфера.
+func (s *LevelStats) Add(leq, dt, level, levelMax float64) {
+	if len(s.samples) == 0 {
+		s.max, s.min = levelMax, level
+	}
+	s.energy += math.Pow(10, leq/10) * dt
+	s.dur += dt
+	s.max = math.Max(s.max, levelMax)
+	s.min = math.Min(s.min, level)
+	s.samples = append(s.samples, level)
+}
+
+func (s *LevelStats) Empty() bool       { return len(s.samples) == 0 }
+func (s *LevelStats) Duration() float64 { return s.dur }
+func (s *LevelStats) Max() float64      { return s.max }
+func (s *LevelStats) Min() float64      { return s.min }
+
+// Leq — эквивалентный уровень за интервал.
+func (s *LevelStats) Leq() float64 {
+	if s.dur <= 0 {
+		return 0
+	}
+	return 10 * math.Log10(s.energy/s.dur)
+}
+
+// Percentile — Ln: уровень, который превышался n% времени (L10, L50, L90).
+func (s *LevelStats) Percentile(n float64) float64 {
+	return Percentile(s.samples, n)
+}
+
+// Percentile — Ln по выборке уровней (values не меняется); 0 — выборка пуста.
+func Percentile(values []float64, n float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	// nearest-rank по возрастанию: Ln — квантиль (100-n)%
+	idx := int(math.Ceil((1-n/100)*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	} else if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+func (s *LevelStats) Reset() {
+	s.energy, s.dur, s.max, s.min = 0, 0, 0, 0
+	s.samples = s.samples[:0]
+}
+
+// PenalizedLevel — энергетическое среднее уровней за периоды заданной длительности
+// со штрафами в дБ: Lden = PenalizedLevel([Lday Levening Lnight], [12 4 8], [0 5 10]).
+func PenalizedLevel(levels, durations, penalties []float64) float64 {
+	var sum, total float64
+	for i, l := range levels {
+		sum += durations[i] * math.Pow(10, (l+penalties[i])/10)
+		total += durations[i]
+	}
+	if total <= 0 {
+		return math.NaN()
+	}
+	return 10 * math.Log10(sum/total)
+}
@@ -0,0 +1,49 @@
+// C:\_Projects_Go\AcousticLog\internal\mathx\stats_test.go
+
+package mathx
+
+import (
+	"slices"
+	"testing"
+)
+
+// Ln по nearest-rank: уровень, превышенный n% времени, — элемент с рангом ⌈(100-n)%·N⌉
+// по возрастанию.
+func TestPercentileNearestRank(t *testing.T) {
+	seq := func(n int) []float64 {
+		v := make([]float64, n)
+		for i := range v {
+			v[i] = float64(n - i) // по убыванию: Percentile сортирует сам
+		}
+		return v
+	}
+	for _, tc := range []struct {
+		values []float64
+		n      float64
+		want   float64
+	}{
+		{nil, 90, 0},
+		{[]float64{42}, 10, 42},
+		{[]float64{42}, 90, 42},
+		{seq(10), 10, 9},
+		{seq(10), 50, 5},
+		{seq(10), 90, 1},
+		{seq(10), 0, 10},
+		{seq(10), 100, 1},
+		{seq(100), 10, 90},
+		{seq(100), 50, 50},
+		{seq(100), 90, 10},
+		{seq(7), 50, 4},
+		{seq(7), 90, 1},
+		{seq(7), 10, 7},
+		{[]float64{30, 50, 40, 50, 60}, 50, 50},
+	} {
+		in := slices.Clone(tc.values)
+		if got := Percentile(tc.values, tc.n); got != tc.want {
+			t.Errorf("L%g(%v) = %g, ожидалось %g", tc.n, tc.values, got, tc.want)
+		}
+		if !slices.Equal(in, tc.values) {
+			t.Errorf("Percentile изменил выборку: %v", tc.values)
+		}
+	}
+}
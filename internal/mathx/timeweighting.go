@@ -0,0 +1,99 @@
+// C:\_Projects_Go\AcousticLog\internal\mathx\timeweighting.go
+
+package mathx
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TimeWeighting — временная характеристика шумомера (IEC 61672-1).
+type TimeWeighting string
+
+const (
+	TimeFast    TimeWeighting = "F"  // 125 мс
+	TimeSlow    TimeWeighting = "S"  // 1 с
+	TimeImpulse TimeWeighting = "I"  // 35 мс нарастание, спад 2.9 дБ/с
+	TimeEq      TimeWeighting = "EQ" // без экспоненты: энергетическое среднее буфера (как раньше)
+)
+
+func ParseTimeWeighting(s string) (TimeWeighting, error) {
+	switch t := TimeWeighting(strings.ToUpper(strings.TrimSpace(s))); t {
+	case TimeFast, TimeSlow, TimeImpulse, TimeEq:
+		return t, nil
+	default:
+		return "", fmt.Errorf("unknown time weighting %q (F|S|I|EQ)", s)
+	}
+}
+
+const (
+	tauFast         = 0.125
+	tauSlow         = 1.0
+	tauImpulse      = 0.035
+	tauImpulseDecay = 1.5 // 10*lg(e)/1.5 ≈ 2.9 дБ/с
+)
+
+// TimeLevels — уровни за буфер в квадратах долей полной шкалы (не в дБ):
+// значение на конце буфера и максимум внутри него.
+type TimeLevels struct {
+	Fast, FastMax       float64
+	Slow, SlowMax       float64
+	Impulse, ImpulseMax float64
+}
+
+// Get — значение на конце буфера и максимум для характеристики t (для EQ — нули).
+func (l TimeLevels) Get(t TimeWeighting) (last, max float64) {
+	switch t {
+	case TimeFast:
+		return l.Fast, l.FastMax
+	case TimeSlow:
+		return l.Slow, l.SlowMax
+	case TimeImpulse:
+		return l.Impulse, l.ImpulseMax
+	}
+	return 0, 0
+}
+
+// TimeWeighter — экспоненциальное усреднение квадрата (уже скорректированного)
+// сигнала посэмплово; состояние непрерывно между буферами.
+type TimeWeighter struct {
+	kF, kS, kI, dI float64
+	f, s, i, iHold float64
+}
+
+func NewTimeWeighter(sampleRate int) *TimeWeighter {
+	fs := float64(sampleRate)
+	k := func(tau float64) float64 { return 1 - math.Exp(-1/(fs*tau)) }
+	return &TimeWeighter{
+		kF: k(tauFast), kS: k(tauSlow), kI: k(tauImpulse),
+		dI: math.Exp(-1 / (fs * tauImpulseDecay)),
+	}
+}
+
+// Process — прогон буфера сэмплов (доли полной шкалы) и уровни за него.
+func (t *TimeWeighter) Process(ys []float64) TimeLevels {
+	var out TimeLevels
+	for _, y := range ys {
+		x2 := y * y
+		t.f += (x2 - t.f) * t.kF
+		t.s += (x2 - t.s) * t.kS
+		t.i += (x2 - t.i) * t.kI
+		// детектор I: быстрый подъём за экспонентой 35 мс, медленный спад 1.5 с
+		if t.i > t.iHold {
+			t.iHold = t.i
+		} else {
+			t.iHold *= t.dI
+		}
+		out.FastMax = math.Max(out.FastMax, t.f)
+		out.SlowMax = math.Max(out.SlowMax, t.s)
+		out.ImpulseMax = math.Max(out.ImpulseMax, t.iHold)
+	}
+	for _, v := range []*float64{&t.f, &t.s, &t.i, &t.iHold} {
+		flushTiny(v)
+	}
+	out.Fast, out.Slow, out.Impulse = t.f, t.s, t.iHold
+	return out
+}
+
+func (t *TimeWeighter) Reset() { t.f, t.s, t.i, t.iHold = 0, 0, 0, 0 }
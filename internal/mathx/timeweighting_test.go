@@ -0,0 +1,85 @@
+// C:\_Projects_Go\AcousticLog\internal\mathx\timeweighting_test.go
+
+package mathx
+
+import (
+	"math"
+	"testing"
+)
+
+const twRate = 16000
+
+// constant — n сэмплов постоянного сигнала a (квадрат — ровно a², без пульсаций синуса).
+func constant(a float64, d float64) []float64 {
+	ys := make([]float64, int(d*twRate))
+	for i := range ys {
+		ys[i] = a
+	}
+	return ys
+}
+
+func db(v float64) float64 { return 10 * math.Log10(v) }
+
+// Нарастание: через τ после включения уровень ниже установившегося на 10·lg(1-1/e) ≈ -1.99 дБ,
+// через 10τ — совпадает с ним.
+func TestTimeWeightingRise(t *testing.T) {
+	for _, tc := range []struct {
+		tw  TimeWeighting
+		tau float64
+	}{
+		{TimeFast, 0.125},
+		{TimeSlow, 1},
+		{TimeImpulse, 0.035},
+	} {
+		w := NewTimeWeighter(twRate)
+		last, _ := w.Process(constant(0.1, tc.tau)).Get(tc.tw)
+		if got, want := db(last)-db(0.01), db(1-1/math.E); math.Abs(got-want) > 0.05 {
+			t.Errorf("%s через τ: %.2f дБ, ожидалось %.2f", tc.tw, got, want)
+		}
+		last, max := w.Process(constant(0.1, 9*tc.tau)).Get(tc.tw)
+		if got := db(last) - db(0.01); math.Abs(got) > 0.01 {
+			t.Errorf("%s через 10τ: %.3f дБ, ожидалось 0", tc.tw, got)
+		}
+		if max < last {
+			t.Errorf("%s: максимум %g меньше значения на конце %g", tc.tw, max, last)
+		}
+	}
+}
+
+// Спад после выключения: F — 34.7 дБ/с, S — 4.3 дБ/с, I — 2.9 дБ/с (IEC 61672-1).
+func TestTimeWeightingDecay(t *testing.T) {
+	for _, tc := range []struct {
+		tw   TimeWeighting
+		rate float64 // дБ/с
+	}{
+		{TimeFast, 34.7},
+		{TimeSlow, 4.3},
+		{TimeImpulse, 2.9},
+	} {
+		w := NewTimeWeighter(twRate)
+		w.Process(constant(0.1, 10))
+		before, _ := w.Process(constant(0.1, 0.01)).Get(tc.tw)
+		after, max := w.Process(constant(0, 0.5)).Get(tc.tw)
+		if got := (db(before) - db(after)) / 0.5; math.Abs(got-tc.rate) > 0.1 {
+			t.Errorf("%s: спад %.2f дБ/с, ожидалось %.1f", tc.tw, got, tc.rate)
+		}
+		// максимум буфера — в его начале, где уровень ещё не упал
+		if math.Abs(db(max)-db(before)) > 0.1 {
+			t.Errorf("%s: максимум %.2f дБ, ожидалось %.2f", tc.tw, db(max), db(before))
+		}
+	}
+}
+
+// I держит короткий импульс: через секунду после 20 мс щелчка I выше F на десятки дБ.
+func TestTimeWeightingImpulseHold(t *testing.T) {
+	w := NewTimeWeighter(twRate)
+	w.Process(constant(0.5, 0.02))
+	tl := w.Process(constant(0, 1))
+	if d := db(tl.Impulse) - db(tl.Fast); d < 20 {
+		t.Errorf("I − F через 1 с = %.1f дБ, ожидалось больше 20", d)
+	}
+	w.Reset()
+	if tl := w.Process(constant(0, 0.01)); tl.Fast != 0 || tl.Slow != 0 || tl.Impulse != 0 {
+		t.Errorf("после Reset: %+v", tl)
+	}
+}
@@ -0,0 +1,155 @@
+// C:\_Projects_Go\AcousticLog\internal\mathx\weighting.go
+
+package mathx
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"strings"
+)
+
+// Weighting — частотная коррекция по IEC 61672-1: A, C или Z (без коррекции).
+type Weighting string
+
+const (
+	WeightingA Weighting = "A"
+	WeightingC Weighting = "C"
+	WeightingZ Weighting = "Z"
+)
+
+func ParseWeighting(s string) (Weighting, error) {
+	switch w := Weighting(strings.ToUpper(strings.TrimSpace(s))); w {
+	case WeightingA, WeightingC, WeightingZ:
+		return w, nil
+	default:
+		return "", fmt.Errorf("unknown frequency weighting %q (A|C|Z)", s)
+	}
+}
+
+// Полюса аналоговых корректирующих фильтров IEC 61672-1 (Гц).
+const (
+	poleF1 = 20.598997
+	poleF2 = 107.65265
+	poleF3 = 737.86223
+	poleF4 = 12194.217
+)
+
+// section — IIR-секция первого порядка: y = b0*x + b1*x[-1] - a1*y[-1].
+type section struct {
+	b0, b1, a1 float64
+	x1, y1     float64
+}
+
+func (s *section) next(x float64) float64 {
+	y := s.b0*x + s.b1*s.x1 - s.a1*s.y1
+	s.x1, s.y1 = x, y
+	return y
+}
+
+// denormalFloor — ниже этого состояние фильтров обнуляется: на цифровой тишине затухание
+// IIR уходит в денормализованные числа, а они считаются на порядки медленнее.
+const denormalFloor = 1e-25
+
+func flushTiny(v *float64) {
+	if math.Abs(*v) < denormalFloor {
+		*v = 0
+	}
+}
+
+func (s *section) flush() { flushTiny(&s.x1); flushTiny(&s.y1) }
+
+func (s *section) response(z complex128) complex128 {
+	zi := 1 / z
+	return (complex(s.b0, 0) + complex(s.b1, 0)*zi) / (1 + complex(s.a1, 0)*zi)
+}
+
+// highPass — s/(s+w) билинейным преобразованием с предыскажением частоты.
+func highPass(f float64, fs float64) section {
+	w := 2 * fs * math.Tan(math.Pi*f/fs)
+	k := 2 * fs
+	return section{b0: k / (k + w), b1: -k / (k + w), a1: -(k - w) / (k + w)}
+}
+
+// lowPass — w/(s+w). Пока полюс заметно ниже Найквиста — билинейно с предыскажением;
+// иначе (f4 = 12.2 кГц при 16/22 кГц) — согласованным z-преобразованием с единичным
+// усилением на 0 Гц, чтобы не заваливать верх сильнее аналогового прототипа.
+func lowPass(f float64, fs float64) section {
+	if f < 0.45*fs {
+		w := 2 * fs * math.Tan(math.Pi*f/fs)
+		k := 2 * fs
+		return section{b0: w / (k + w), b1: w / (k + w), a1: -(k - w) / (k + w)}
+	}
+	p := math.Exp(-2 * math.Pi * f / fs)
+	return section{b0: 1 - p, a1: -p}
+}
+
+// WeightingFilter — корректирующий фильтр для заданной частоты дискретизации.
+// Состояние сохраняется между буферами, поэтому фильтр один на весь поток.
+// Точность на верхних частотах зависит от частоты дискретизации (класс 1 — от 48 кГц).
+type WeightingFilter struct {
+	w    Weighting
+	secs []section
+	gain float64
+}
+
+func NewWeightingFilter(w Weighting, sampleRate int) *WeightingFilter {
+	fs := float64(sampleRate)
+	f := &WeightingFilter{w: w, gain: 1}
+	switch w {
+	case WeightingA:
+		f.secs = []section{
+			highPass(poleF1, fs), highPass(poleF1, fs),
+			highPass(poleF2, fs), highPass(poleF3, fs),
+			lowPass(poleF4, fs), lowPass(poleF4, fs),
+		}
+	case WeightingC:
+		f.secs = []section{
+			highPass(poleF1, fs), highPass(poleF1, fs),
+			lowPass(poleF4, fs), lowPass(poleF4, fs),
+		}
+	}
+	// нормировка: 0 дБ на 1 кГц, как требует стандарт
+	if g := cmplx.Abs(f.response(1000, fs)); g > 0 {
+		f.gain = 1 / g
+	}
+	return f
+}
+
+func (f *WeightingFilter) Weighting() Weighting { return f.w }
+
+func (f *WeightingFilter) response(freq, fs float64) complex128 {
+	z := cmplx.Exp(complex(0, 2*math.Pi*freq/fs))
+	h := complex(1, 0)
+	for i := range f.secs {
+		h *= f.secs[i].response(z)
+	}
+	return h
+}
+
+// Next — один сэмпл (в долях полной шкалы) через фильтр.
+func (f *WeightingFilter) Next(x float64) float64 {
+	for i := range f.secs {
+		x = f.secs[i].next(x)
+	}
+	return x * f.gain
+}
+
+// ApplyInt16 — скорректированные сэмплы буфера (доли полной шкалы).
+func (f *WeightingFilter) ApplyInt16(s []int16) []float64 {
+	out := make([]float64, len(s))
+	for i, v := range s {
+		out[i] = f.Next(float64(v) / 32768.0)
+	}
+	for i := range f.secs {
+		f.secs[i].flush()
+	}
+	return out
+}
+
+// Reset — сброс состояния (после разрыва потока).
+func (f *WeightingFilter) Reset() {
+	for i := range f.secs {
+		f.secs[i].x1, f.secs[i].y1 = 0, 0
+	}
+}
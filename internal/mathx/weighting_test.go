@@ -0,0 +1,68 @@
+// C:\_Projects_Go\AcousticLog\internal\mathx\weighting_test.go
+
+package mathx
+
+import (
+	"math"
+	"testing"
+)
+
+// gainDB — усиление фильтра на синусе freq в установившемся режиме (первые 0.5 с — переходный процесс).
+func gainDB(w Weighting, sampleRate int, freq float64) float64 {
+	f := NewWeightingFilter(w, sampleRate)
+	s := make([]int16, 2*sampleRate)
+	for i := range s {
+		s[i] = int16(16384 * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+	}
+	ys := f.ApplyInt16(s)[sampleRate/2:]
+	in := make([]float64, len(ys))
+	for i, v := range s[sampleRate/2:] {
+		in[i] = float64(v) / 32768.0
+	}
+	return 20 * math.Log10(RMS(ys)/RMS(in))
+}
+
+// На 1 кГц все коррекции дают 0 дБ при любой частоте дискретизации.
+func TestWeightingNormalizedAt1kHz(t *testing.T) {
+	for _, w := range []Weighting{WeightingA, WeightingC, WeightingZ} {
+		for _, fs := range []int{16000, 44100, 48000} {
+			if g := gainDB(w, fs, 1000); math.Abs(g) > 0.05 {
+				t.Errorf("%s @%d Гц: 1 кГц = %+.2f дБ, ожидалось 0", w, fs, g)
+			}
+		}
+	}
+}
+
+// Номинальные A и C из IEC 61672-1 (таблица 3) при 48 кГц. До 2 кГц фильтр совпадает
+// с таблицей с точностью её округления; выше билинейный ФНЧ поднимает верх
+// (≈ +0.3 дБ на 4 кГц, +0.7 дБ на 8 кГц) — там проверяем допуск класса 1.
+func TestWeightingResponse(t *testing.T) {
+	for _, tc := range []struct {
+		w    Weighting
+		freq float64
+		want float64
+		tol  float64
+	}{
+		{WeightingA, 31.5, -39.4, 0.2},
+		{WeightingA, 63, -26.2, 0.2},
+		{WeightingA, 100, -19.1, 0.2},
+		{WeightingA, 250, -8.6, 0.2},
+		{WeightingA, 500, -3.2, 0.2},
+		{WeightingA, 2000, 1.2, 0.2},
+		{WeightingA, 4000, 1.0, 1.0},
+		{WeightingA, 8000, -1.1, 1.5},
+		{WeightingC, 31.5, -3.0, 0.2},
+		{WeightingC, 63, -0.8, 0.2},
+		{WeightingC, 100, -0.3, 0.2},
+		{WeightingC, 500, 0.0, 0.2},
+		{WeightingC, 2000, -0.2, 0.2},
+		{WeightingC, 4000, -0.8, 1.0},
+		{WeightingC, 8000, -3.0, 1.5},
+		{WeightingZ, 63, 0, 0.05},
+		{WeightingZ, 8000, 0, 0.05},
+	} {
+		if g := gainDB(tc.w, 48000, tc.freq); math.Abs(g-tc.want) > tc.tol {
+			t.Errorf("%s %g Гц: %+.2f дБ, ожидалось %+.1f ± %.2f", tc.w, tc.freq, g, tc.want, tc.tol)
+		}
+	}
+}
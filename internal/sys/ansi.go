@@ -0,0 +1,45 @@
+// C:\_Projects_Go\AcousticLog\internal\sys\ansi.go
+
+package sys
+
+import (
+	"fmt"
+	"os"
+)
+
+// Цвета ANSI. Переменные, а не константы: если stdout не терминал
+// (перенаправлен в файл/journald), EnableANSI обнуляет их, чтобы в логах не было мусора.
+var (
+	ClrReset   = "\x1b[0m"
+	ClrBold    = "\x1b[1m"
+	ClrRed     = "\x1b[31m"
+	ClrGreen   = "\x1b[32m"
+	ClrYellow  = "\x1b[33m"
+	ClrBlue    = "\x1b[34m"
+	ClrMagenta = "\x1b[35m"
+	ClrCyan    = "\x1b[36m"
+	ClrGray    = "\x1b[90m"
+)
+
+// ansiOK — stdout является терминалом с поддержкой ANSI (выставляется в EnableANSI).
+var ansiOK = true
+
+func disableColors() {
+	ansiOK = false
+	ClrReset, ClrBold, ClrRed, ClrGreen, ClrYellow = "", "", "", "", ""
+	ClrBlue, ClrMagenta, ClrCyan, ClrGray = "", "", "", ""
+}
+
+// noColorEnv — соглашение https://no-color.org и "тупые" терминалы.
+func noColorEnv() bool {
+	_, set := os.LookupEnv("NO_COLOR")
+	return set || os.Getenv("TERM") == "dumb"
+}
+
+// ClearConsole — очистка экрана и курсор в начало (ANSI); вне терминала — ничего не делает.
+func ClearConsole() {
+	if !ansiOK {
+		return
+	}
+	fmt.Print("\x1b[H\x1b[2J\x1b[3J")
+}
@@ -0,0 +1,20 @@
+// C:\_Projects_Go\AcousticLog\internal\sys\ansi_unix.go
+
+//go:build unix
+
+package sys
+
+import "os"
+
+// EnableANSI — терминалы POSIX понимают ANSI сами; нужно лишь понять,
+// что stdout — терминал, иначе цвета отключаются.
+func EnableANSI() {
+	if noColorEnv() {
+		disableColors()
+		return
+	}
+	fi, err := os.Stdout.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		disableColors()
+	}
+}
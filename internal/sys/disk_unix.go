@@ -0,0 +1,20 @@
+// C:\_Projects_Go\AcousticLog\internal\sys\disk_unix.go
+
+//go:build unix
+
+package sys
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+// Returns MB available to the current (unprivileged) user, as GetDiskFreeSpaceExW does on Windows
+func GetFreeDiskSpaceMB(path string) (uint64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize) / (1024 * 1024), nil
+}